@@ -1,30 +1,27 @@
 package aniverse
 
 import (
+	"aniverse/internal/config"
 	"aniverse/internal/controller"
-	"os"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
-func Start() {
-	app := fiber.New()
+func Start(cfg *config.Config) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler: controller.ErrorHandler,
+	})
+	app.Use(requestid.New())
 	app.Use(logger.New())
 	app.Use(cors.New())
+	app.Use(controller.WithDeadline(cfg.Server.RequestTimeout))
 
 	// Initialize Providers
-	controller := controller.NewBaseController()
+	controller := controller.NewBaseController(cfg)
+	controller.Routes(app)
 
-	// Routes
-	app.Get("/search", controller.Search)
-	app.Get("/info", controller.GetAnimeInfo)
-	app.Get("/watch", controller.WatchEpisode)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
-	}
-	app.Listen(":" + port)
+	app.Listen(":" + cfg.Server.Port)
 }
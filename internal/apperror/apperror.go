@@ -0,0 +1,182 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"aniverse/internal/httpclient"
+)
+
+// Kind classifies an error and decides the HTTP status it is rendered with.
+type Kind string
+
+const (
+	KindBadRequest          Kind = "BAD_REQUEST"
+	KindUnauthorized        Kind = "UNAUTHORIZED"
+	KindForbidden           Kind = "FORBIDDEN"
+	KindNotFound            Kind = "NOT_FOUND"
+	KindMappingNotFound     Kind = "MAPPING_NOT_FOUND"
+	KindExtractionFailed    Kind = "EXTRACTION_FAILED"
+	KindUpstreamUnavailable Kind = "UPSTREAM_UNAVAILABLE"
+	KindRateLimited         Kind = "RATE_LIMITED"
+	KindTimeout             Kind = "TIMEOUT"
+	KindInternal            Kind = "INTERNAL"
+)
+
+// Kind sentinels, for use with errors.Is, e.g. errors.Is(err, apperror.ErrNotFound).
+var (
+	ErrBadRequest          = &Error{Kind: KindBadRequest}
+	ErrUnauthorized        = &Error{Kind: KindUnauthorized}
+	ErrForbidden           = &Error{Kind: KindForbidden}
+	ErrNotFound            = &Error{Kind: KindNotFound}
+	ErrMappingNotFound     = &Error{Kind: KindMappingNotFound}
+	ErrExtractionFailed    = &Error{Kind: KindExtractionFailed}
+	ErrUpstreamUnavailable = &Error{Kind: KindUpstreamUnavailable}
+	ErrTimeout             = &Error{Kind: KindTimeout}
+	ErrInternal            = &Error{Kind: KindInternal}
+)
+
+// Error is an error with a Kind, the upstream provider it came from (if any)
+// and a message that is safe to show to API clients.
+type Error struct {
+	Kind     Kind
+	Provider string
+	Message  string
+	Err      error
+}
+
+// New creates an Error of the given kind.
+func New(kind Kind, provider string, message string) *Error {
+	return &Error{Kind: kind, Provider: provider, Message: message}
+}
+
+// Wrap creates an Error of the given kind that wraps err.
+func Wrap(kind Kind, provider string, err error, message string) *Error {
+	return &Error{Kind: kind, Provider: provider, Message: message, Err: err}
+}
+
+func BadRequest(message string) *Error {
+	return New(KindBadRequest, "", message)
+}
+
+func NotFound(provider string, format string, args ...interface{}) *Error {
+	return New(KindNotFound, provider, fmt.Sprintf(format, args...))
+}
+
+func Forbidden(provider string, format string, args ...interface{}) *Error {
+	return New(KindForbidden, provider, fmt.Sprintf(format, args...))
+}
+
+func MappingNotFound(provider string, anilistID string) *Error {
+	return New(KindMappingNotFound, provider, fmt.Sprintf("no provider mapping found for AniList ID %s", anilistID))
+}
+
+func ExtractionFailed(provider string, err error) *Error {
+	return Wrap(KindExtractionFailed, provider, err, "failed to extract video sources")
+}
+
+func UpstreamUnavailable(provider string, err error) *Error {
+	return Wrap(KindUpstreamUnavailable, provider, err, provider+" is unavailable")
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = string(e.Kind)
+	}
+	if e.Provider != "" {
+		msg = e.Provider + ": " + msg
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind sentinel matching e's Kind.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || t.Message != "" || t.Err != nil || t.Provider != "" {
+		return false
+	}
+	return t.Kind == e.Kind
+}
+
+// Status returns the HTTP status code for e's Kind.
+func (e *Error) Status() int {
+	return StatusOf(e.Kind)
+}
+
+// StatusOf returns the HTTP status code errors of kind are rendered with.
+func StatusOf(kind Kind) int {
+	switch kind {
+	case KindBadRequest:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound, KindMappingNotFound:
+		return http.StatusNotFound
+	case KindExtractionFailed, KindUpstreamUnavailable:
+		return http.StatusBadGateway
+	case KindRateLimited:
+		return http.StatusServiceUnavailable
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// FromStatus classifies an unexpected upstream HTTP status.
+func FromStatus(provider string, status int) *Error {
+	switch {
+	case status == http.StatusNotFound:
+		return NotFound(provider, "not found on %s", provider)
+	case status == http.StatusForbidden || status == http.StatusUnauthorized:
+		return Forbidden(provider, "access denied by %s", provider)
+	default:
+		return UpstreamUnavailable(provider, fmt.Errorf("received status %d", status))
+	}
+}
+
+// Upstream attributes an error from a call to provider. Errors that are
+// already classified keep their kind (gaining the provider if missing),
+// cancellations and rate limits pass through unchanged and anything else
+// becomes UpstreamUnavailable.
+func Upstream(provider string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		if appErr.Provider != "" {
+			return err
+		}
+		return Wrap(appErr.Kind, provider, err, appErr.Message)
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, httpclient.ErrRateLimited) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Wrap(KindTimeout, provider, err, provider+" did not respond in time")
+	}
+
+	var statusErr *httpclient.StatusError
+	if errors.As(err, &statusErr) {
+		classified := FromStatus(provider, statusErr.Code)
+		classified.Err = err
+		return classified
+	}
+
+	return UpstreamUnavailable(provider, err)
+}
@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// TTLs for each kind of upstream data we cache.
+const (
+	TTLMedia         = 6 * time.Hour
+	TTLSearch        = 1 * time.Hour
+	TTLEpisodes      = 15 * time.Minute
+	TTLEpisodeTitles = 12 * time.Hour
+	TTLSchedule      = 15 * time.Minute
+	TTLSource        = 30 * time.Minute // Fallback when a source URL carries no expiry
+)
+
+// sourceExpiryMargin is subtracted from a signed URL's expiry so that we never
+// hand out a cached source that is about to stop working.
+const sourceExpiryMargin = 2 * time.Minute
+
+// keyPrefix namespaces every key written by Aniverse.
+const keyPrefix = "aniverse:"
+
+// DefaultMaxEntries bounds the in-memory store when New or NewMemory is given
+// no positive maxEntries.
+const DefaultMaxEntries = 10000
+
+// Backend names accepted by New, as set in config.CacheConfig.Backend.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Store is a key/value cache with per-entry TTLs.
+type Store interface {
+	// Get returns the value stored under key and whether it was found.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for the given TTL.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key from the store.
+	Delete(ctx context.Context, key string) error
+	// Stats returns the hit/miss/eviction counters collected so far.
+	Stats() Stats
+}
+
+// Stats holds counters used to tune TTLs.
+type Stats struct {
+	Backend   string `json:"backend"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// counters tracks hits, misses and evictions for a Store implementation.
+type counters struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func (c *counters) record(found bool) {
+	if found {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+func (c *counters) stats(backend string, entries int) Stats {
+	return Stats{
+		Backend:   backend,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Entries:   entries,
+	}
+}
+
+// New creates the Store for backend, "memory" or "redis". An unusable Redis
+// URL falls back to the in-memory LRU, which keeps at most maxEntries values.
+func New(backend string, redisURL string, maxEntries int) Store {
+	if backend == BackendRedis {
+		store, err := NewRedis(redisURL)
+		if err == nil {
+			return store
+		}
+		log.Printf("Falling back to in-memory cache, invalid Redis URL: %v", err)
+	}
+
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return NewMemory(maxEntries)
+}
+
+// Key joins the given parts into a namespaced cache key.
+func Key(parts ...string) string {
+	return keyPrefix + strings.Join(parts, ":")
+}
+
+// GetJSON decodes the value stored under key into v and reports whether it was found.
+// Store errors are logged and treated as a miss. A nil store never hits.
+func GetJSON(ctx context.Context, s Store, key string, v interface{}) bool {
+	if s == nil {
+		return false
+	}
+
+	data, found, err := s.Get(ctx, key)
+	if err != nil {
+		log.Printf("Cache get %s failed: %v", key, err)
+		return false
+	}
+	if !found {
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Printf("Cache decode %s failed: %v", key, err)
+		return false
+	}
+	return true
+}
+
+// SetJSON encodes v and stores it under key for the given TTL.
+// Failures are logged and otherwise ignored.
+func SetJSON(ctx context.Context, s Store, key string, v interface{}, ttl time.Duration) {
+	if s == nil || ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Cache encode %s failed: %v", key, err)
+		return
+	}
+
+	if err := s.Set(ctx, key, data, ttl); err != nil {
+		log.Printf("Cache set %s failed: %v", key, err)
+	}
+}
+
+// SourceTTL returns how long an extracted source may be cached, based on the
+// earliest expiry found in the signed URLs. Falls back to TTLSource.
+func SourceTTL(urls ...string) time.Duration {
+	var earliest time.Time
+	for _, raw := range urls {
+		expiry, ok := signedURLExpiry(raw)
+		if !ok {
+			continue
+		}
+		if earliest.IsZero() || expiry.Before(earliest) {
+			earliest = expiry
+		}
+	}
+
+	if earliest.IsZero() {
+		return TTLSource
+	}
+
+	ttl := time.Until(earliest) - sourceExpiryMargin
+	if ttl <= 0 {
+		return 0
+	}
+	return ttl
+}
+
+// signedURLExpiry reads the unix expiry timestamp from a signed URL's query string.
+func signedURLExpiry(raw string) (time.Time, bool) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	query := u.Query()
+	for _, param := range []string{"expires", "expiry", "exp", "e"} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		if ts, err := strconv.ParseInt(value, 10, 64); err == nil && ts > 0 {
+			return time.Unix(ts, 0), true
+		}
+	}
+	return time.Time{}, false
+}
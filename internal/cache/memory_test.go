@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	store := NewMemory(2)
+	ctx := context.Background()
+
+	store.Set(ctx, "a", []byte("1"), time.Minute)
+	store.Set(ctx, "b", []byte("2"), time.Minute)
+	// Reading "a" makes "b" the least recently used.
+	if _, found, _ := store.Get(ctx, "a"); !found {
+		t.Fatal("Get(a) missed")
+	}
+	store.Set(ctx, "c", []byte("3"), time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, found, _ := store.Get(ctx, key); found != want {
+			t.Errorf("Get(%s) found = %v, want %v", key, found, want)
+		}
+	}
+
+	stats := store.Stats()
+	if stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("Stats = %+v, want 1 eviction and 2 entries", stats)
+	}
+}
+
+func TestMemorySetRefreshesEntry(t *testing.T) {
+	store := NewMemory(2)
+	ctx := context.Background()
+
+	store.Set(ctx, "a", []byte("1"), time.Minute)
+	store.Set(ctx, "b", []byte("2"), time.Minute)
+	// Overwriting "a" replaces its value and makes it the most recently used.
+	store.Set(ctx, "a", []byte("updated"), time.Minute)
+	store.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if value, found, _ := store.Get(ctx, "a"); !found || string(value) != "updated" {
+		t.Errorf("Get(a) = %q, %v; want \"updated\", true", value, found)
+	}
+	if _, found, _ := store.Get(ctx, "b"); found {
+		t.Error("Get(b) hit, want it evicted")
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	store := NewMemory(10)
+	ctx := context.Background()
+
+	store.Set(ctx, "short", []byte("1"), time.Millisecond)
+	store.Set(ctx, "long", []byte("2"), time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found, _ := store.Get(ctx, "short"); found {
+		t.Error("Get(short) hit after its TTL")
+	}
+	if _, found, _ := store.Get(ctx, "long"); !found {
+		t.Error("Get(long) missed before its TTL")
+	}
+	// Expired entries are dropped when read, not counted as evictions.
+	if stats := store.Stats(); stats.Entries != 1 || stats.Evictions != 0 {
+		t.Errorf("Stats = %+v, want 1 entry and no evictions", stats)
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	store := NewMemory(10)
+	ctx := context.Background()
+
+	store.Set(ctx, "a", []byte("1"), time.Minute)
+	store.Delete(ctx, "a")
+	if _, found, _ := store.Get(ctx, "a"); found {
+		t.Error("Get(a) hit after Delete")
+	}
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Store backed by a Redis server. Expired keys and evictions are
+// handled by Redis itself, so only hits and misses are counted here.
+type Redis struct {
+	client *redis.Client
+	counters
+}
+
+// NewRedis creates a Redis store for the instance described by redisURL,
+// e.g. "redis://localhost:6379/0".
+func NewRedis(redisURL string) (*Redis, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Redis{client: redis.NewClient(opts)}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		r.record(false)
+		return nil, false, nil
+	}
+	if err != nil {
+		r.record(false)
+		return nil, false, err
+	}
+	r.record(true)
+	return data, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+func (r *Redis) Stats() Stats {
+	return r.stats(BackendRedis, -1)
+}
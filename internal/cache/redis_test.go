@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	store, err := NewRedis("redis://" + server.Addr() + "/0")
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	return store, server
+}
+
+func TestRedisGetSet(t *testing.T) {
+	store, _ := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value, found, err := store.Get(ctx, "k")
+	if err != nil || !found || string(value) != "v" {
+		t.Fatalf("Get = %q, %v, %v; want \"v\", true, nil", value, found, err)
+	}
+
+	if err := store.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, found, err := store.Get(ctx, "k"); err != nil || found {
+		t.Fatalf("Get after Delete = %v, %v; want false, nil", found, err)
+	}
+
+	stats := store.Stats()
+	if stats.Backend != BackendRedis || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Stats = %+v, want 1 hit and 1 miss on %s", stats, BackendRedis)
+	}
+}
+
+func TestRedisMiss(t *testing.T) {
+	store, _ := newTestRedis(t)
+
+	value, found, err := store.Get(context.Background(), "missing")
+	if err != nil || found || value != nil {
+		t.Fatalf("Get = %q, %v, %v; want nil, false, nil", value, found, err)
+	}
+}
+
+func TestRedisTTLExpiry(t *testing.T) {
+	store, server := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl := server.TTL("k"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want %v", ttl, time.Minute)
+	}
+
+	server.FastForward(time.Minute + time.Second)
+	if _, found, err := store.Get(ctx, "k"); err != nil || found {
+		t.Fatalf("Get after expiry = %v, %v; want false, nil", found, err)
+	}
+}
+
+func TestRedisJSONRoundTrip(t *testing.T) {
+	store, _ := newTestRedis(t)
+	ctx := context.Background()
+
+	type media struct {
+		ID     string   `json:"id"`
+		Genres []string `json:"genres"`
+	}
+	want := media{ID: "21", Genres: []string{"Action", "Adventure"}}
+	key := Key("anilist", "media", "21")
+
+	SetJSON(ctx, store, key, want, time.Minute)
+
+	var got media
+	if !GetJSON(ctx, store, key, &got) {
+		t.Fatal("GetJSON missed a value set by SetJSON")
+	}
+	if got.ID != want.ID || len(got.Genres) != 2 || got.Genres[1] != "Adventure" {
+		t.Errorf("GetJSON = %+v, want %+v", got, want)
+	}
+
+	if GetJSON(ctx, store, Key("anilist", "media", "1"), &got) {
+		t.Error("GetJSON hit a key that was never set")
+	}
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	store, err := NewRedis("redis://" + server.Addr() + "/0?max_retries=-1&dial_timeout=100ms")
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	server.Close()
+	ctx := context.Background()
+
+	// A store that cannot reach Redis behaves as a cache that always misses.
+	SetJSON(ctx, store, "k", "v", time.Minute)
+	var value string
+	if GetJSON(ctx, store, "k", &value) {
+		t.Error("GetJSON hit with Redis down")
+	}
+	if _, _, err := store.Get(ctx, "k"); err == nil {
+		t.Error("Get returned no error with Redis down")
+	}
+}
+
+func TestNewFallsBackToMemory(t *testing.T) {
+	store := New(BackendRedis, "not a url", 10)
+	if _, ok := store.(*Memory); !ok {
+		t.Fatalf("New with an invalid Redis URL = %T, want *Memory", store)
+	}
+
+	store = New(BackendRedis, "redis://"+miniredis.RunT(t).Addr(), 10)
+	if _, ok := store.(*Redis); !ok {
+		t.Fatalf("New with a valid Redis URL = %T, want *Redis", store)
+	}
+}
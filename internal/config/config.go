@@ -0,0 +1,392 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is read when CONFIG_PATH is unset. A missing file is not an
+// error; the defaults and environment are used instead.
+const DefaultPath = "config.yaml"
+
+// Config holds every setting Aniverse needs at startup.
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Mapping   MappingConfig   `yaml:"mapping"`
+	AniList   AniListConfig   `yaml:"anilist"`
+	GogoAnime GogoAnimeConfig `yaml:"gogoanime"`
+	MangaDex  MangaDexConfig  `yaml:"mangadex"`
+	MAL       MALConfig       `yaml:"mal"`
+	TVDB      TVDBConfig      `yaml:"tvdb"`
+	Gogocdn   GogocdnConfig   `yaml:"gogocdn"`
+	StreamSB  StreamSBConfig  `yaml:"streamsb"`
+	Proxy     ProxyConfig     `yaml:"proxy"`
+}
+
+type ServerConfig struct {
+	Port           string        `yaml:"port"`
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+	// AdminToken enables the admin API; it is disabled when empty.
+	AdminToken string `yaml:"adminToken"`
+}
+
+type CacheConfig struct {
+	// Backend is "memory" or "redis". When empty, Redis is used if RedisURL is set.
+	Backend    string `yaml:"backend"`
+	RedisURL   string `yaml:"redisUrl"`
+	MaxEntries int    `yaml:"maxEntries"`
+}
+
+type MappingConfig struct {
+	DBPath string `yaml:"dbPath"`
+}
+
+type AniListConfig struct {
+	// APIURL is the GraphQL endpoint; SiteURL is sent as Origin and hosts OAuth.
+	APIURL  string `yaml:"apiUrl"`
+	SiteURL string `yaml:"siteUrl"`
+	// ClientID, ClientSecret and RedirectURL are the OAuth client registered
+	// on AniList. Login is disabled without a client ID.
+	ClientID     string `yaml:"clientId"`
+	ClientSecret string `yaml:"clientSecret"`
+	RedirectURL  string `yaml:"redirectUrl"`
+}
+
+type GogoAnimeConfig struct {
+	BaseURL string `yaml:"baseUrl"`
+	AjaxURL string `yaml:"ajaxUrl"`
+}
+
+type MangaDexConfig struct {
+	APIURL string `yaml:"apiUrl"`
+	// Language is the translation chapters are listed in.
+	Language string `yaml:"language"`
+}
+
+type MALConfig struct {
+	BaseURL string `yaml:"baseUrl"`
+	APIURL  string `yaml:"apiUrl"`
+	// ClientID, ClientSecret and RedirectURL are the OAuth client registered
+	// on MAL. Login is disabled without a client ID; the secret is optional
+	// for clients of the "other" type.
+	ClientID     string `yaml:"clientId"`
+	ClientSecret string `yaml:"clientSecret"`
+	RedirectURL  string `yaml:"redirectUrl"`
+}
+
+type TVDBConfig struct {
+	BaseURL string `yaml:"baseUrl"`
+	APIKey  string `yaml:"apiKey"`
+}
+
+// GogocdnConfig holds the AES keys and IV used to decrypt gogocdn sources.
+type GogocdnConfig struct {
+	EncryptionKey string `yaml:"encryptionKey"`
+	DecryptionKey string `yaml:"decryptionKey"`
+	IV            string `yaml:"iv"`
+	// Hosts are the embed domains gogocdn serves its player from; their
+	// subdomains match too.
+	Hosts []string `yaml:"hosts"`
+}
+
+// StreamSBConfig describes the StreamSB family of embed hosts.
+type StreamSBConfig struct {
+	// Hosts are the embed domains of the family; their subdomains match too.
+	Hosts []string `yaml:"hosts"`
+	// SourcesPath is the path of the sources API on the embed host, which
+	// StreamSB renumbers now and then.
+	SourcesPath string `yaml:"sourcesPath"`
+}
+
+// ProxyConfig controls the HLS proxy links handed to clients.
+type ProxyConfig struct {
+	// BaseURL is the public URL of this server used in proxy links; links
+	// are root-relative when empty.
+	BaseURL string `yaml:"baseUrl"`
+	// Secret signs proxy links. A random secret is generated when empty.
+	Secret string `yaml:"secret"`
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:           "3000",
+			RequestTimeout: 30 * time.Second,
+		},
+		Cache: CacheConfig{
+			MaxEntries: 10000,
+		},
+		Mapping: MappingConfig{
+			DBPath: "data/mappings.db",
+		},
+		AniList: AniListConfig{
+			APIURL:  "https://graphql.anilist.co",
+			SiteURL: "https://anilist.co",
+		},
+		GogoAnime: GogoAnimeConfig{
+			BaseURL: "https://gogoanime3.co",
+			AjaxURL: "https://ajax.gogocdn.net",
+		},
+		MangaDex: MangaDexConfig{
+			APIURL:   "https://api.mangadex.org",
+			Language: "en",
+		},
+		MAL: MALConfig{
+			BaseURL: "https://myanimelist.net",
+			APIURL:  "https://api.myanimelist.net/v2",
+		},
+		TVDB: TVDBConfig{
+			BaseURL: "https://api.thetvdb.com",
+		},
+		Gogocdn: GogocdnConfig{
+			EncryptionKey: "37911490979715163134003223491201",
+			DecryptionKey: "54674138327930866480207815084989",
+			IV:            "3134003223491201",
+			Hosts: []string{
+				"embtaku.pro", "embtaku.com", "s3taku.com", "gotaku1.com",
+				"playtaku.net", "playtaku.online", "gogohd.net", "gogohd.pro",
+				"goone.pro", "anihdplay.com",
+			},
+		},
+		StreamSB: StreamSBConfig{
+			Hosts: []string{
+				"streamsss.net", "watchsb.com", "streamsb.net", "sbplay.org",
+				"sbfull.com", "sbani.pro", "embedsb.com", "lvturbo.com",
+			},
+			SourcesPath: "sources50",
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path
+// (CONFIG_PATH or DefaultPath when empty) and environment overrides, then
+// validates it.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != "" || os.Getenv("CONFIG_PATH") != ""
+	if path == "" {
+		path = os.Getenv("CONFIG_PATH")
+	}
+	if path == "" {
+		path = DefaultPath
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	default:
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides settings from the environment.
+func (c *Config) applyEnv() error {
+	// Applied in order, so TVDB_API_KEY wins over the older TVDB_CLIENT.
+	overrides := []struct {
+		name   string
+		target *string
+	}{
+		{"PORT", &c.Server.Port},
+		{"ADMIN_TOKEN", &c.Server.AdminToken},
+		{"CACHE_BACKEND", &c.Cache.Backend},
+		{"REDIS_URL", &c.Cache.RedisURL},
+		{"MAPPING_DB_PATH", &c.Mapping.DBPath},
+		{"ANILIST_API_URL", &c.AniList.APIURL},
+		{"ANILIST_CLIENT_ID", &c.AniList.ClientID},
+		{"ANILIST_CLIENT_SECRET", &c.AniList.ClientSecret},
+		{"ANILIST_REDIRECT_URL", &c.AniList.RedirectURL},
+		{"GOGOANIME_URL", &c.GogoAnime.BaseURL},
+		{"GOGOANIME_AJAX_URL", &c.GogoAnime.AjaxURL},
+		{"MANGADEX_API_URL", &c.MangaDex.APIURL},
+		{"MANGADEX_LANGUAGE", &c.MangaDex.Language},
+		{"MAL_URL", &c.MAL.BaseURL},
+		{"MAL_API_URL", &c.MAL.APIURL},
+		{"MAL_CLIENT_ID", &c.MAL.ClientID},
+		{"MAL_CLIENT_SECRET", &c.MAL.ClientSecret},
+		{"MAL_REDIRECT_URL", &c.MAL.RedirectURL},
+		{"TVDB_URL", &c.TVDB.BaseURL},
+		{"TVDB_CLIENT", &c.TVDB.APIKey},
+		{"TVDB_API_KEY", &c.TVDB.APIKey},
+		{"GOGOCDN_ENCRYPTION_KEY", &c.Gogocdn.EncryptionKey},
+		{"GOGOCDN_DECRYPTION_KEY", &c.Gogocdn.DecryptionKey},
+		{"GOGOCDN_IV", &c.Gogocdn.IV},
+		{"STREAMSB_SOURCES_PATH", &c.StreamSB.SourcesPath},
+		{"PROXY_BASE_URL", &c.Proxy.BaseURL},
+		{"PROXY_SECRET", &c.Proxy.Secret},
+	}
+	for _, override := range overrides {
+		if value, ok := os.LookupEnv(override.name); ok {
+			*override.target = value
+		}
+	}
+
+	if value := os.Getenv("REQUEST_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid REQUEST_TIMEOUT %q: %w", value, err)
+		}
+		c.Server.RequestTimeout = timeout
+	}
+
+	if value := os.Getenv("GOGOCDN_HOSTS"); value != "" {
+		c.Gogocdn.Hosts = strings.Split(value, ",")
+	}
+	if value := os.Getenv("STREAMSB_HOSTS"); value != "" {
+		c.StreamSB.Hosts = strings.Split(value, ",")
+	}
+
+	if value := os.Getenv("CACHE_MAX_ENTRIES"); value != "" {
+		maxEntries, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid CACHE_MAX_ENTRIES %q: %w", value, err)
+		}
+		c.Cache.MaxEntries = maxEntries
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		fail("server.port %q is not a valid port", c.Server.Port)
+	}
+	if c.Server.RequestTimeout <= 0 {
+		fail("server.requestTimeout must be positive")
+	}
+
+	c.Cache.Backend = strings.ToLower(c.Cache.Backend)
+	if c.Cache.Backend == "" && c.Cache.RedisURL != "" {
+		c.Cache.Backend = "redis"
+	}
+	switch c.Cache.Backend {
+	case "", "memory":
+	case "redis":
+		if c.Cache.RedisURL == "" {
+			fail("cache.redisUrl is required for the redis backend")
+		}
+	default:
+		fail("cache.backend %q must be \"memory\" or \"redis\"", c.Cache.Backend)
+	}
+	if c.Cache.MaxEntries <= 0 {
+		fail("cache.maxEntries must be positive")
+	}
+
+	if c.Mapping.DBPath == "" {
+		fail("mapping.dbPath is required")
+	}
+
+	for name, value := range map[string]string{
+		"anilist.apiUrl":    c.AniList.APIURL,
+		"anilist.siteUrl":   c.AniList.SiteURL,
+		"gogoanime.baseUrl": c.GogoAnime.BaseURL,
+		"gogoanime.ajaxUrl": c.GogoAnime.AjaxURL,
+		"mangadex.apiUrl":   c.MangaDex.APIURL,
+		"mal.baseUrl":       c.MAL.BaseURL,
+		"mal.apiUrl":        c.MAL.APIURL,
+		"tvdb.baseUrl":      c.TVDB.BaseURL,
+	} {
+		if err := validateURL(value); err != nil {
+			fail("%s: %v", name, err)
+		}
+	}
+
+	if len(c.Gogocdn.Hosts) == 0 {
+		fail("gogocdn.hosts must list at least one host")
+	}
+	for name, hosts := range map[string][]string{
+		"gogocdn.hosts":  c.Gogocdn.Hosts,
+		"streamsb.hosts": c.StreamSB.Hosts,
+	} {
+		for _, host := range hosts {
+			if host = strings.TrimSpace(host); host == "" || strings.Contains(host, "/") {
+				fail("%s: %q is not a host name", name, host)
+			}
+		}
+	}
+	if strings.Trim(c.StreamSB.SourcesPath, "/") == "" {
+		fail("streamsb.sourcesPath is required")
+	}
+
+	if c.AniList.ClientID != "" {
+		if c.AniList.ClientSecret == "" {
+			fail("anilist.clientSecret is required with anilist.clientId")
+		}
+		if err := validateURL(c.AniList.RedirectURL); err != nil {
+			fail("anilist.redirectUrl: %v", err)
+		}
+	}
+
+	if c.MAL.ClientID != "" {
+		if err := validateURL(c.MAL.RedirectURL); err != nil {
+			fail("mal.redirectUrl: %v", err)
+		}
+	}
+
+	if c.MangaDex.Language == "" {
+		fail("mangadex.language is required")
+	}
+
+	if c.Proxy.BaseURL != "" {
+		if err := validateURL(c.Proxy.BaseURL); err != nil {
+			fail("proxy.baseUrl: %v", err)
+		}
+	}
+
+	for name, value := range map[string]string{
+		"gogocdn.encryptionKey": c.Gogocdn.EncryptionKey,
+		"gogocdn.decryptionKey": c.Gogocdn.DecryptionKey,
+	} {
+		if n := len(value); n != 16 && n != 24 && n != 32 {
+			fail("%s must be 16, 24 or 32 bytes, got %d", name, n)
+		}
+	}
+	if len(c.Gogocdn.IV) != 16 {
+		fail("gogocdn.iv must be 16 bytes, got %d", len(c.Gogocdn.IV))
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// validateURL requires an absolute http(s) URL.
+func validateURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", value)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/mapping"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mappingOverride is the request body accepted by PutMapping.
+type mappingOverride struct {
+	Sub string `json:"sub"`
+	Dub string `json:"dub"`
+}
+
+// RequireAdmin rejects requests that do not carry the configured admin bearer
+// token. When no token is configured the admin API is disabled entirely.
+func (provider *BaseController) RequireAdmin(c *fiber.Ctx) error {
+	token := provider.adminToken
+	if token == "" {
+		return apperror.Forbidden("", "Admin API is disabled.")
+	}
+
+	expected := "Bearer " + token
+	if subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), []byte(expected)) != 1 {
+		return apperror.New(apperror.KindUnauthorized, "", "Invalid admin token.")
+	}
+	return c.Next()
+}
+
+// GetMapping returns the persisted mapping for an AniList ID.
+func (provider *BaseController) GetMapping(c *fiber.Ctx) error {
+	providerID, anilistID, err := provider.mappingParams(c)
+	if err != nil {
+		return err
+	}
+
+	record, err := provider.mappings.Get(providerID, anilistID)
+	if err != nil {
+		return fmt.Errorf("reading mapping for AniList ID %s: %w", anilistID, err)
+	}
+	if record == nil {
+		return apperror.MappingNotFound(providerID, anilistID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(record)
+}
+
+// PutMapping pins a provider's sub/dub slugs for an AniList ID so they are never recomputed.
+// For a chapter provider, 'sub' is the manga's ID.
+func (provider *BaseController) PutMapping(c *fiber.Ctx) error {
+	providerID, anilistID, err := provider.mappingParams(c)
+	if err != nil {
+		return err
+	}
+
+	var body mappingOverride
+	if err := c.BodyParser(&body); err != nil {
+		return apperror.BadRequest("Invalid mapping body: " + err.Error())
+	}
+	if body.Sub == "" && body.Dub == "" {
+		return apperror.BadRequest("At least one of 'sub' or 'dub' is required.")
+	}
+	if _, ok := provider.registry.EpisodeProvider(providerID); !ok && (body.Sub == "" || body.Dub != "") {
+		return apperror.BadRequest("Chapter providers take the manga's ID as 'sub' and no 'dub'.")
+	}
+
+	record := mapping.Record{
+		AniListID: anilistID,
+		Sub:       body.Sub,
+		Dub:       body.Dub,
+		Score:     1,
+		Pinned:    true,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := provider.mappings.Put(providerID, record); err != nil {
+		return fmt.Errorf("storing mapping for AniList ID %s: %w", anilistID, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(record)
+}
+
+// DeleteMapping removes the mapping for an AniList ID so it is recomputed on next use.
+func (provider *BaseController) DeleteMapping(c *fiber.Ctx) error {
+	providerID, anilistID, err := provider.mappingParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := provider.mappings.Delete(providerID, anilistID); err != nil {
+		return fmt.Errorf("deleting mapping for AniList ID %s: %w", anilistID, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// mappingParams validates the ':anilistId' route parameter and the optional
+// 'provider' query parameter: an episode or chapter provider, defaulting to
+// the preferred episode provider.
+func (provider *BaseController) mappingParams(c *fiber.Ctx) (string, string, error) {
+	if provider.mappings == nil {
+		return "", "", fiber.NewError(fiber.StatusServiceUnavailable, "Mapping store is not available.")
+	}
+
+	anilistID := c.Params("anilistId")
+	if _, err := strconv.Atoi(anilistID); err != nil {
+		return "", "", apperror.BadRequest("Invalid 'anilistId' parameter.")
+	}
+
+	providerID := c.Query("provider")
+	if providerID == "" {
+		episodeProviders := provider.registry.EpisodeProviders()
+		if len(episodeProviders) == 0 {
+			return "", "", errNoEpisodeProvider
+		}
+		providerID = episodeProviders[0].ID()
+	} else if !provider.isMappedProvider(providerID) {
+		return "", "", apperror.NotFound("", "Unknown provider: %s", providerID)
+	}
+
+	return providerID, anilistID, nil
+}
+
+// isMappedProvider reports whether id is a provider whose mappings are
+// stored: an episode or a chapter provider.
+func (provider *BaseController) isMappedProvider(id string) bool {
+	if _, ok := provider.registry.EpisodeProvider(id); ok {
+		return true
+	}
+	_, ok := provider.registry.ChapterProvider(id)
+	return ok
+}
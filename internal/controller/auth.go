@@ -0,0 +1,409 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/session"
+)
+
+// sessionExpiration is how long a login lasts without a visit.
+const sessionExpiration = 30 * 24 * time.Hour
+
+var errNotSignedIn = apperror.New(apperror.KindUnauthorized, "", "Not signed in. Log in at /auth/{provider}/login first.")
+
+// newSessionStore keeps sessions in memory behind an HTTP-only cookie.
+func newSessionStore() *session.Store {
+	return session.New(session.Config{
+		Expiration:     sessionExpiration,
+		KeyLookup:      "cookie:aniverse_session",
+		CookieHTTPOnly: true,
+		CookieSameSite: "Lax",
+	})
+}
+
+func stateKey(trackerID string) string { return "oauth-state:" + trackerID }
+func tokenKey(trackerID string) string { return "oauth-token:" + trackerID }
+
+// Login redirects to the ':tracker' provider (e.g. anilist) to sign in.
+func (provider *BaseController) Login(c *fiber.Ctx) error {
+	tracker, err := provider.trackerByID(c.Params("tracker"))
+	if err != nil {
+		return err
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return err
+	}
+
+	authorizeURL, err := tracker.AuthorizeURL(state)
+	if err != nil {
+		return err
+	}
+
+	sess, err := provider.sessions.Get(c)
+	if err != nil {
+		return fmt.Errorf("loading session: %w", err)
+	}
+	sess.Set(stateKey(tracker.ID()), state)
+	if err := sess.Save(); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	return c.Redirect(authorizeURL, fiber.StatusFound)
+}
+
+// Callback completes a login started by Login and stores the token in the
+// session.
+func (provider *BaseController) Callback(c *fiber.Ctx) error {
+	tracker, err := provider.trackerByID(c.Params("tracker"))
+	if err != nil {
+		return err
+	}
+
+	if reason := c.Query("error"); reason != "" {
+		return apperror.BadRequest("Login was not completed: " + reason)
+	}
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		return apperror.BadRequest("Missing 'code' or 'state' parameter.")
+	}
+
+	sess, err := provider.sessions.Get(c)
+	if err != nil {
+		return fmt.Errorf("loading session: %w", err)
+	}
+
+	// The state ties the callback to the Login of this browser.
+	expected, _ := sess.Get(stateKey(tracker.ID())).(string)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(state)) != 1 {
+		return apperror.BadRequest("Invalid login state. Start again at /auth/" + tracker.ID() + "/login.")
+	}
+	sess.Delete(stateKey(tracker.ID()))
+
+	ctx := c.UserContext()
+	token, err := tracker.Exchange(ctx, code, state)
+	if err != nil {
+		return apperror.Upstream(tracker.ID(), err)
+	}
+
+	viewer, err := tracker.Viewer(ctx, token)
+	if err != nil {
+		return apperror.Upstream(tracker.ID(), err)
+	}
+
+	if err := setToken(sess, tracker, token); err != nil {
+		return err
+	}
+
+	// A new session ID on login keeps a planted cookie from being hijacked.
+	if err := sess.Regenerate(); err != nil {
+		return fmt.Errorf("regenerating session: %w", err)
+	}
+	if err := sess.Save(); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"provider": tracker.ID(),
+		"user":     viewer,
+	})
+}
+
+// Logout forgets the ':tracker' token of the session.
+func (provider *BaseController) Logout(c *fiber.Ctx) error {
+	tracker, err := provider.trackerByID(c.Params("tracker"))
+	if err != nil {
+		return err
+	}
+
+	sess, err := provider.sessions.Get(c)
+	if err != nil {
+		return fmt.Errorf("loading session: %w", err)
+	}
+	sess.Delete(tokenKey(tracker.ID()))
+	if err := sess.Save(); err != nil {
+		return fmt.Errorf("saving session: %w", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// MyList returns the lists of the signed-in user, from the tracker given by
+// 'provider' or the first one signed in to. 'status' (e.g. CURRENT) keeps a
+// single list.
+func (provider *BaseController) MyList(c *fiber.Ctx) error {
+	var status types.ListStatus
+	if value := c.Query("status"); value != "" {
+		status = types.ListStatus(strings.ToUpper(value))
+		valid := false
+		for _, known := range types.ListStatuses {
+			valid = valid || status == known
+		}
+		if !valid {
+			return apperror.BadRequest("Invalid 'status' parameter: " + value)
+		}
+	}
+
+	tracker, token, err := provider.signedIn(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.UserContext()
+	viewer, err := tracker.Viewer(ctx, token)
+	if err != nil {
+		return apperror.Upstream(tracker.ID(), err)
+	}
+	lists, err := tracker.MediaLists(ctx, token, status)
+	if err != nil {
+		return apperror.Upstream(tracker.ID(), err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"provider": tracker.ID(),
+		"user":     viewer,
+		"lists":    lists,
+	})
+}
+
+// UpdateListEntry adds the anime with AniList ID ':id' to the signed-in
+// user's list, or changes its entry, on the tracker given by 'provider' or
+// the first one signed in to. The JSON body sets any of status, progress
+// (watched episodes) and score (0-10).
+func (provider *BaseController) UpdateListEntry(c *fiber.Ctx) error {
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+
+	var update types.ListEntryUpdate
+	if err := json.Unmarshal(c.Body(), &update); err != nil {
+		return apperror.BadRequest("Invalid JSON body: " + err.Error())
+	}
+	if update.Status != "" {
+		update.Status = types.ListStatus(strings.ToUpper(string(update.Status)))
+		valid := false
+		for _, known := range types.ListStatuses {
+			valid = valid || update.Status == known
+		}
+		if !valid {
+			return apperror.BadRequest("Invalid 'status': " + string(update.Status))
+		}
+	}
+	if update.Progress != nil && *update.Progress < 0 {
+		return apperror.BadRequest("'progress' must not be negative.")
+	}
+	if update.Score != nil && (*update.Score < 0 || *update.Score > 10) {
+		return apperror.BadRequest("'score' must be between 0 and 10.")
+	}
+	if update.Status == "" && update.Progress == nil && update.Score == nil {
+		return apperror.BadRequest("Nothing to update. Set 'status', 'progress' or 'score'.")
+	}
+
+	tracker, token, err := provider.signedIn(c)
+	if err != nil {
+		return err
+	}
+
+	meta := provider.registry.Meta()
+	if meta == nil {
+		return errNoMetaProvider
+	}
+
+	ctx := c.UserContext()
+	anime, err := meta.GetMedia(ctx, id)
+	if err != nil {
+		return apperror.Upstream(meta.ID(), err)
+	}
+
+	entry, err := tracker.UpdateEntry(ctx, token, anime, update)
+	if err != nil {
+		return apperror.Upstream(tracker.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(entry)
+}
+
+// signedIn returns the tracker given by 'provider', or the first one the
+// session is signed in to, with the session's token for it.
+func (provider *BaseController) signedIn(c *fiber.Ctx) (provider.Tracker, *types.OAuthToken, error) {
+	trackers := provider.registry.Trackers()
+	if id := c.Query("provider"); id != "" {
+		tracker, err := provider.trackerByID(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		trackers = append(trackers[:0], tracker)
+	}
+
+	for _, tracker := range trackers {
+		token, err := provider.token(c, tracker)
+		if err != nil {
+			return nil, nil, err
+		}
+		if token != nil {
+			return tracker, token, nil
+		}
+	}
+	return nil, nil, errNotSignedIn
+}
+
+// signedInAll returns every tracker the session is signed in to, with its
+// token. A tracker whose token cannot be read or renewed right now is left
+// out, unless it is the only one.
+func (provider *BaseController) signedInAll(c *fiber.Ctx) (trackers []provider.Tracker, tokens []*types.OAuthToken, err error) {
+	var failure error
+	for _, tracker := range provider.registry.Trackers() {
+		token, err := provider.token(c, tracker)
+		if err != nil {
+			log.Printf("Error loading %s token: %v", tracker.ID(), err)
+			if failure == nil {
+				failure = err
+			}
+			continue
+		}
+		if token != nil {
+			trackers = append(trackers, tracker)
+			tokens = append(tokens, token)
+		}
+	}
+	if len(trackers) == 0 && failure != nil {
+		return nil, nil, failure
+	}
+	if len(trackers) == 0 {
+		return nil, nil, errNotSignedIn
+	}
+	return trackers, tokens, nil
+}
+
+// SaveProgress records episode 'ep' of the anime 'id' as watched on every
+// tracker the session is signed in to, completing the show on its last
+// episode. The player calls it once an episode has mostly played, so that
+// opening an episode does not change anyone's list. It returns the trackers
+// updated; one failing does not stop the others.
+func (provider *BaseController) SaveProgress(c *fiber.Ctx) error {
+	id := c.Query("id")
+	if id == "" {
+		return apperror.BadRequest("Missing 'id' parameter.")
+	}
+	episode, err := strconv.Atoi(c.Query("ep"))
+	if err != nil || episode < 1 {
+		return apperror.BadRequest("Invalid 'ep' parameter. It should be a positive integer.")
+	}
+
+	trackers, tokens, err := provider.signedInAll(c)
+	if err != nil {
+		return err
+	}
+
+	meta := provider.registry.Meta()
+	if meta == nil {
+		return errNoMetaProvider
+	}
+
+	ctx := c.UserContext()
+	anime, err := meta.GetMedia(ctx, id)
+	if err != nil {
+		return apperror.Upstream(meta.ID(), err)
+	}
+
+	saved := []string{}
+	var failure error
+	for i, tracker := range trackers {
+		if err := tracker.SaveProgress(ctx, tokens[i], anime, episode); err != nil {
+			log.Printf("Error saving progress of %s episode %d to %s: %v", anime.ID, episode, tracker.ID(), err)
+			if failure == nil {
+				failure = apperror.Upstream(tracker.ID(), err)
+			}
+			continue
+		}
+		saved = append(saved, tracker.ID())
+	}
+	if len(saved) == 0 {
+		return failure
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"providers": saved})
+}
+
+// token returns the session's token for tracker, or nil when not signed in.
+func (provider *BaseController) token(c *fiber.Ctx, tracker provider.Tracker) (*types.OAuthToken, error) {
+	sess, err := provider.sessions.Get(c)
+	if err != nil {
+		return nil, fmt.Errorf("loading session: %w", err)
+	}
+
+	encoded, _ := sess.Get(tokenKey(tracker.ID())).(string)
+	if encoded == "" {
+		return nil, nil
+	}
+
+	var token types.OAuthToken
+	if err := json.Unmarshal([]byte(encoded), &token); err != nil {
+		return nil, fmt.Errorf("decoding token: %w", err)
+	}
+	if !token.Expired() {
+		return &token, nil
+	}
+
+	// Renew the token, or forget it when the tracker will not. Any other
+	// failure, such as the tracker being down, keeps the login for a retry.
+	refreshed, err := tracker.Refresh(c.UserContext(), &token)
+	var appErr *apperror.Error
+	if err != nil && !(errors.As(err, &appErr) && appErr.Kind == apperror.KindUnauthorized) {
+		return nil, apperror.Upstream(tracker.ID(), err)
+	}
+	if err != nil {
+		log.Printf("Error refreshing %s token: %v", tracker.ID(), err)
+		sess.Delete(tokenKey(tracker.ID()))
+		refreshed = nil
+	} else if err := setToken(sess, tracker, refreshed); err != nil {
+		return nil, err
+	}
+	if err := sess.Save(); err != nil {
+		return nil, fmt.Errorf("saving session: %w", err)
+	}
+	return refreshed, nil
+}
+
+// setToken stores token in the session; the caller saves it.
+func setToken(sess *session.Session, tracker provider.Tracker, token *types.OAuthToken) error {
+	encoded, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("encoding token: %w", err)
+	}
+	sess.Set(tokenKey(tracker.ID()), string(encoded))
+	return nil
+}
+
+// trackerByID returns the tracker registered under id.
+func (provider *BaseController) trackerByID(id string) (provider.Tracker, error) {
+	tracker, ok := provider.registry.Tracker(id)
+	if !ok {
+		return nil, apperror.NotFound("", "Unknown provider: %s", id)
+	}
+	return tracker, nil
+}
+
+// randomState returns an unguessable OAuth state value.
+func randomState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating state: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -1,28 +1,85 @@
 package controller
 
 import (
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
 	"aniverse/internal/crawler"
 	"aniverse/internal/extractor"
+	"aniverse/internal/graph"
+	"aniverse/internal/httpclient"
+	"aniverse/internal/mapping"
+	"aniverse/internal/provider"
 	"aniverse/internal/provider/anilist"
 	"aniverse/internal/provider/gogoanime"
 	"aniverse/internal/provider/mal"
+	"aniverse/internal/provider/mangadex"
+	"aniverse/internal/proxy"
+	"log"
+
+	"github.com/gofiber/fiber/v2/middleware/session"
 )
 
 type BaseController struct {
-	anilist     *anilist.AniListBase
-	gogoanime   *gogoanime.GogoAnime
+	registry    *provider.Registry
 	myanimelist *mal.MyAnimeList
-	extractor   *extractor.Gogocdn
+	extractors  *extractor.Registry
 	crawler     *crawler.BaseCrawler
+	mapper      *mapping.Mapper
+	mappings    *mapping.Store
+	cache       cache.Store
+	client      *httpclient.Client
+	streaming   *httpclient.Client
+	proxy       *proxy.Proxy
+	sessions    *session.Store
+	graph       *graph.Schema
+	adminToken  string
 }
 
-func NewBaseController() *BaseController {
+func NewBaseController(cfg *config.Config) *BaseController {
 	crawler := crawler.NewBaseCrawler()
-	return &BaseController{
-		anilist:     anilist.NewAniListBase(),
-		gogoanime:   gogoanime.NewGogoAnime(),
-		myanimelist: mal.NewMyAnimeList(),
-		extractor:   extractor.NewGogocdn(crawler),
+	store := cache.New(cfg.Cache.Backend, cfg.Cache.RedisURL, cfg.Cache.MaxEntries)
+
+	mappings, err := mapping.OpenStore(cfg.Mapping.DBPath)
+	if err != nil {
+		log.Printf("Mapping persistence disabled, cannot open %s: %v", cfg.Mapping.DBPath, err)
+	}
+
+	registry := provider.NewRegistry()
+	registerProviders(registry, cfg, store, httpclient.Default)
+
+	myanimelist := mal.NewMyAnimeList(cfg.MAL, store, httpclient.Default)
+	registry.Register(myanimelist)
+
+	base := &BaseController{
+		registry:    registry,
+		myanimelist: myanimelist,
+		extractors:  newExtractors(cfg, crawler, store),
 		crawler:     crawler,
+		mapper:      mapping.NewMapper(registry, myanimelist, mappings),
+		mappings:    mappings,
+		cache:       store,
+		client:      httpclient.Default,
+		streaming:   httpclient.Streaming,
+		proxy:       proxy.New(cfg.Proxy),
+		sessions:    newSessionStore(),
+		adminToken:  cfg.Server.AdminToken,
 	}
+	base.graph = graph.NewSchema(registry, graphBackend{controller: base})
+	return base
+}
+
+// registerProviders registers every metadata, episode, source and chapter provider.
+// Providers registered first are preferred.
+func registerProviders(registry *provider.Registry, cfg *config.Config, store cache.Store, client *httpclient.Client) {
+	registry.Register(anilist.NewAniListBase(cfg.AniList, store, client))
+	registry.Register(gogoanime.NewGogoAnime(cfg.GogoAnime, store, client))
+	registry.Register(mangadex.NewMangaDex(cfg.MangaDex, store, client))
+}
+
+// newExtractors registers the extractor of every supported embed host.
+func newExtractors(cfg *config.Config, crawler *crawler.BaseCrawler, store cache.Store) *extractor.Registry {
+	extractors := extractor.NewRegistry()
+	extractors.Register(extractor.NewGogocdn(cfg.Gogocdn, crawler, store))
+	extractors.Register(extractor.NewStreamSB(cfg.StreamSB, crawler, store))
+	return extractors
 }
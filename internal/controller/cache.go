@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// CacheStats reports the cache hit/miss/eviction counters.
+func (provider *BaseController) CacheStats(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(provider.cache.Stats())
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WithDeadline binds each request's user context to the given timeout and
+// cancels it once the handler returns, so that slow requests stop every
+// outbound call made on their behalf. It only enforces the deadline: fasthttp
+// does not report clients that disconnect, so their requests run until the
+// handler returns or the timeout fires.
+func WithDeadline(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
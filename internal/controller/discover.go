@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/types"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxPerPage is the largest page AniList will return.
+const maxPerPage = 50
+
+var errNoDiscoveryProvider = fiber.NewError(fiber.StatusServiceUnavailable, "No discovery provider available.")
+
+// Trending lists the anime trending right now.
+func (provider *BaseController) Trending(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 20)
+	if err != nil {
+		return err
+	}
+
+	discovery := provider.registry.Discovery()
+	if discovery == nil {
+		return errNoDiscoveryProvider
+	}
+
+	result, err := discovery.Trending(c.UserContext(), page, perPage)
+	if err != nil {
+		return apperror.Upstream(discovery.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// Popular lists the most popular anime of all time.
+func (provider *BaseController) Popular(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 20)
+	if err != nil {
+		return err
+	}
+
+	discovery := provider.registry.Discovery()
+	if discovery == nil {
+		return errNoDiscoveryProvider
+	}
+
+	result, err := discovery.Popular(c.UserContext(), page, perPage)
+	if err != nil {
+		return apperror.Upstream(discovery.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// Seasonal lists the anime of the 'season' and 'year' parameters, which
+// default to the current season.
+func (provider *BaseController) Seasonal(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 20)
+	if err != nil {
+		return err
+	}
+
+	currentSeason, currentYear := seasonOf(time.Now())
+
+	season := currentSeason
+	if value := c.Query("season"); value != "" {
+		season = types.Season(strings.ToUpper(value))
+		switch season {
+		case types.SeasonWinter, types.SeasonSpring, types.SeasonSummer, types.SeasonFall:
+		default:
+			return apperror.BadRequest("Invalid 'season' parameter. It should be WINTER, SPRING, SUMMER or FALL.")
+		}
+	}
+
+	year := currentYear
+	if value := c.Query("year"); value != "" {
+		year, err = strconv.Atoi(value)
+		if err != nil || year < 1940 {
+			return apperror.BadRequest("Invalid 'year' parameter.")
+		}
+	}
+
+	discovery := provider.registry.Discovery()
+	if discovery == nil {
+		return errNoDiscoveryProvider
+	}
+
+	result, err := discovery.Seasonal(c.UserContext(), season, year, page, perPage)
+	if err != nil {
+		return apperror.Upstream(discovery.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// Upcoming lists announced anime that have not started airing.
+func (provider *BaseController) Upcoming(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 20)
+	if err != nil {
+		return err
+	}
+
+	discovery := provider.registry.Discovery()
+	if discovery == nil {
+		return errNoDiscoveryProvider
+	}
+
+	result, err := discovery.Upcoming(c.UserContext(), page, perPage)
+	if err != nil {
+		return apperror.Upstream(discovery.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// pageParams reads the optional 'page' and 'per_page' parameters. 'perPage'
+// is accepted for 'per_page', as the /v1/episodes contract spells it.
+func pageParams(c *fiber.Ctx, defaultPerPage int) (int, int, error) {
+	page, perPage := 1, defaultPerPage
+
+	if value := c.Query("page"); value != "" {
+		p, err := strconv.Atoi(value)
+		if err != nil || p < 1 {
+			return 0, 0, apperror.BadRequest("Invalid 'page' parameter. It should be a positive integer.")
+		}
+		page = p
+	}
+
+	if value := c.Query("per_page", c.Query("perPage")); value != "" {
+		pp, err := strconv.Atoi(value)
+		if err != nil || pp < 1 || pp > maxPerPage {
+			return 0, 0, apperror.BadRequest("Invalid 'per_page' parameter. It should be between 1 and " + strconv.Itoa(maxPerPage) + ".")
+		}
+		perPage = pp
+	}
+
+	return page, perPage, nil
+}
+
+// seasonOf returns the anime season and year t falls in.
+func seasonOf(t time.Time) (types.Season, int) {
+	switch t.Month() {
+	case time.January, time.February, time.March:
+		return types.SeasonWinter, t.Year()
+	case time.April, time.May, time.June:
+		return types.SeasonSpring, t.Year()
+	case time.July, time.August, time.September:
+		return types.SeasonSummer, t.Year()
+	default:
+		return types.SeasonFall, t.Year()
+	}
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"aniverse/internal/openapi"
+	"aniverse/view"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// OpenAPI returns the OpenAPI 3 document of the API.
+func (provider *BaseController) OpenAPI(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(openapi.Spec())
+}
+
+// Docs renders interactive docs for the document served by OpenAPI.
+func (provider *BaseController) Docs(c *fiber.Ctx) error {
+	c.Set("Content-Type", "text/html")
+	if err := view.Docs("/openapi.json").Render(c.Context(), c.Response().BodyWriter()); err != nil {
+		return fmt.Errorf("rendering docs view: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/types"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetEpisodes lists the episodes of the anime 'id' a page at a time, without
+// the rest of AnimeInfo. 'sort' is asc (the default) or desc by episode
+// number, and 'dub=true' keeps only the episodes with a dub.
+func (provider *BaseController) GetEpisodes(c *fiber.Ctx) error {
+	id := c.Query("id")
+	if id == "" {
+		return apperror.BadRequest("Missing 'id' parameter.")
+	}
+
+	page, perPage, err := pageParams(c, maxPerPage)
+	if err != nil {
+		return err
+	}
+
+	descending := false
+	switch strings.ToLower(c.Query("sort", "asc")) {
+	case "asc":
+	case "desc":
+		descending = true
+	default:
+		return apperror.BadRequest("Invalid 'sort' parameter. It should be asc or desc.")
+	}
+
+	dubOnly := false
+	if value := c.Query("dub"); value != "" {
+		if dubOnly, err = strconv.ParseBool(value); err != nil {
+			return apperror.BadRequest("Invalid 'dub' parameter. It should be true or false.")
+		}
+	}
+
+	meta := provider.registry.Meta()
+	if meta == nil {
+		return errNoMetaProvider
+	}
+
+	info, err := meta.GetMedia(c.UserContext(), id)
+	if err != nil {
+		return apperror.Upstream(meta.ID(), err)
+	}
+	episodesResult, err := provider.mapper.GetEpisodes(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	episodes := mergeEpisodes(info.Episodes, episodesResult.Episodes)
+	if dubOnly {
+		dubbed := episodes[:0]
+		for _, episode := range episodes {
+			if episode.HasDub {
+				dubbed = append(dubbed, episode)
+			}
+		}
+		episodes = dubbed
+	}
+	if descending {
+		for i, j := 0, len(episodes)-1; i < j; i, j = i+1, j-1 {
+			episodes[i], episodes[j] = episodes[j], episodes[i]
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(episodePage(episodes, page, perPage))
+}
+
+// episodePage cuts page 'page' of perPage episodes out of episodes.
+func episodePage(episodes []types.Episode, page int, perPage int) *types.EpisodePage {
+	total := len(episodes)
+	lastPage := (total + perPage - 1) / perPage
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	results := []types.Episode{}
+	if page <= lastPage && total > 0 {
+		start := (page - 1) * perPage
+		end := start + perPage
+		if end > total {
+			end = total
+		}
+		results = episodes[start:end]
+	}
+
+	return &types.EpisodePage{
+		PageInfo: types.PageInfo{
+			Total:       total,
+			PerPage:     perPage,
+			CurrentPage: page,
+			LastPage:    lastPage,
+			HasNextPage: page < lastPage,
+		},
+		Results: results,
+	}
+}
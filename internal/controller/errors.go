@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/httpclient"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errNoMetaProvider    = fiber.NewError(fiber.StatusServiceUnavailable, "No metadata provider available.")
+	errNoEpisodeProvider = fiber.NewError(fiber.StatusServiceUnavailable, "No episode provider available.")
+	errNoMangaProvider   = fiber.NewError(fiber.StatusServiceUnavailable, "No manga metadata provider available.")
+	errNoChapterProvider = fiber.NewError(fiber.StatusServiceUnavailable, "No chapter provider available.")
+	errNoPeopleProvider  = fiber.NewError(fiber.StatusServiceUnavailable, "No character and staff provider available.")
+)
+
+// errorBody is the JSON envelope every failed request is answered with.
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Provider  string `json:"provider,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// ErrorHandler renders errors returned by handlers as an errorBody. Typed
+// apperrors keep their kind and provider, upstream rate limits become 503 with
+// Retry-After so clients back off, and anything unclassified is a 500.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	status, detail := classify(c, err)
+	if status >= fiber.StatusInternalServerError {
+		log.Printf("%s %s failed (request %s): %v", c.Method(), c.OriginalURL(), detail.RequestID, err)
+	}
+
+	return c.Status(status).JSON(errorBody{Error: detail})
+}
+
+// classify maps err onto a status code and envelope.
+func classify(c *fiber.Ctx, err error) (int, errorDetail) {
+	detail := errorDetail{RequestID: c.GetRespHeader(fiber.HeaderXRequestID)}
+
+	var (
+		rateLimited *httpclient.RateLimitError
+		appErr      *apperror.Error
+		fiberErr    *fiber.Error
+	)
+	switch {
+	case errors.As(err, &rateLimited):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(rateLimited.RetryAfterSeconds()))
+		detail.Code = string(apperror.KindRateLimited)
+		detail.Message = "Upstream " + rateLimited.Host + " is rate limiting us, retry later."
+		if errors.As(err, &appErr) {
+			detail.Provider = appErr.Provider
+		}
+		return apperror.StatusOf(apperror.KindRateLimited), detail
+
+	case errors.Is(err, context.DeadlineExceeded):
+		detail.Code = string(apperror.KindTimeout)
+		detail.Message = "The request took too long to complete."
+		if errors.As(err, &appErr) {
+			detail.Provider = appErr.Provider
+		}
+		return apperror.StatusOf(apperror.KindTimeout), detail
+
+	case errors.As(err, &appErr):
+		detail.Code = string(appErr.Kind)
+		detail.Message = appErr.Message
+		detail.Provider = appErr.Provider
+		if detail.Message == "" {
+			detail.Message = string(appErr.Kind)
+		}
+		return appErr.Status(), detail
+
+	case errors.As(err, &fiberErr):
+		detail.Code = codeForStatus(fiberErr.Code)
+		detail.Message = fiberErr.Message
+		return fiberErr.Code, detail
+	}
+
+	detail.Code = string(apperror.KindInternal)
+	detail.Message = "Internal server error."
+	return fiber.StatusInternalServerError, detail
+}
+
+// codeForStatus names plain fiber errors (e.g. unknown routes) after the
+// apperror kind with the same status.
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return string(apperror.KindBadRequest)
+	case fiber.StatusUnauthorized:
+		return string(apperror.KindUnauthorized)
+	case fiber.StatusForbidden:
+		return string(apperror.KindForbidden)
+	case fiber.StatusNotFound:
+		return string(apperror.KindNotFound)
+	case fiber.StatusServiceUnavailable:
+		return string(apperror.KindUpstreamUnavailable)
+	}
+	if status >= fiber.StatusInternalServerError {
+		return string(apperror.KindInternal)
+	}
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/types"
+	"context"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// graphQLRequest is the body of a GraphQL request.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQL executes a query against the schema of internal/graph. POST takes
+// the usual JSON body; GET takes 'query', 'operationName' and 'variables'
+// (as JSON) as query parameters. Failed fields are reported in "errors" with
+// the code and provider of the REST error envelope as extensions.
+func (provider *BaseController) GraphQL(c *fiber.Ctx) error {
+	var request graphQLRequest
+	if c.Method() == fiber.MethodGet {
+		request.Query = c.Query("query")
+		request.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return apperror.BadRequest("Invalid 'variables' parameter: " + err.Error())
+			}
+		}
+	} else if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return apperror.BadRequest("Invalid JSON body: " + err.Error())
+	}
+
+	if request.Query == "" {
+		return apperror.BadRequest("Missing 'query'.")
+	}
+
+	response := provider.graph.Exec(c.UserContext(), request.Query, request.OperationName, request.Variables)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// graphBackend fetches episodes and sources for the GraphQL resolvers the way
+// GetAnimeInfo and WatchEpisode do.
+type graphBackend struct {
+	controller *BaseController
+}
+
+func (b graphBackend) Episodes(ctx context.Context, anime *types.AnimeInfo) ([]types.Episode, error) {
+	episodesResult, err := b.controller.mapper.GetEpisodes(ctx, anime.ID)
+	if err != nil {
+		return nil, err
+	}
+	return mergeEpisodes(anime.Episodes, episodesResult.Episodes), nil
+}
+
+func (b graphBackend) Source(ctx context.Context, animeID string, episode int, dub bool) (*types.Source, error) {
+	targetEpisode, _, err := b.controller.episodeSource(ctx, episodeRequest{animeID: animeID, episode: episode, dub: dub})
+	if err != nil {
+		return nil, err
+	}
+	return &targetEpisode.Source, nil
+}
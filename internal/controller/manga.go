@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/mapping"
+	"aniverse/internal/types"
+	"aniverse/view"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchManga lists manga matching 'q' and the filters read by searchFilter.
+func (provider *BaseController) SearchManga(c *fiber.Ctx) error {
+	query := c.Query("q")
+
+	manga := provider.registry.Manga()
+	if manga == nil {
+		return errNoMangaProvider
+	}
+
+	filter, err := searchFilter(c, manga.MangaFormats())
+	if err != nil {
+		return err
+	}
+	if query == "" && isEmptyFilter(filter) {
+		return apperror.BadRequest("Missing 'q' parameter or filter.")
+	}
+	filter.Search = query
+
+	results, err := manga.SearchManga(c.UserContext(), filter)
+	if err != nil {
+		return apperror.Upstream(manga.ID(), err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(results)
+}
+
+// GetMangaInfo returns a manga by AniList ID with its publisher from MAL and
+// its chapters from the first chapter provider that carries it.
+func (provider *BaseController) GetMangaInfo(c *fiber.Ctx) error {
+	id := c.Query("id")
+	if id == "" {
+		return apperror.BadRequest("Missing 'id' parameter.")
+	}
+
+	manga := provider.registry.Manga()
+	if manga == nil {
+		return errNoMangaProvider
+	}
+
+	ctx := c.UserContext()
+	info, err := manga.GetManga(ctx, id)
+	if err != nil {
+		return apperror.Upstream(manga.ID(), err)
+	}
+
+	// The publisher is best effort; the rest of the info is still useful without it.
+	if info.IDMal != "" {
+		serialization, err := provider.myanimelist.GetMangaSerialization(ctx, info.IDMal)
+		if err != nil {
+			log.Printf("Error fetching serialization from MyAnimeList for ID %s: %v", info.IDMal, err)
+		} else {
+			info.Publisher = &serialization
+		}
+	}
+
+	chaptersResult, err := provider.mapper.GetChapters(ctx, id)
+	if err != nil {
+		return err
+	}
+	info.Chapters = chaptersResult.Chapters
+
+	return c.Status(fiber.StatusOK).JSON(info)
+}
+
+// ReadChapter renders the pages of a chapter, selected with 'chapter' (a
+// chapter ID from /manga/info) of the manga with AniList ID 'id'.
+func (provider *BaseController) ReadChapter(c *fiber.Ctx) error {
+	id := c.Query("id")
+	chapterID := c.Query("chapter")
+	if id == "" || chapterID == "" {
+		return apperror.BadRequest("Missing 'id' or 'chapter' parameter.")
+	}
+
+	manga := provider.registry.Manga()
+	if manga == nil {
+		return errNoMangaProvider
+	}
+	if len(provider.registry.ChapterProviders()) == 0 {
+		return errNoChapterProvider
+	}
+
+	ctx := c.UserContext()
+	info, err := manga.GetManga(ctx, id)
+	if err != nil {
+		return apperror.Upstream(manga.ID(), err)
+	}
+
+	chapterMap, err := provider.mapper.FindChapterMap(ctx, id)
+	if errors.Is(err, mapping.ErrNoMapping) {
+		return apperror.MappingNotFound("", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	chapters, err := chapterMap.Provider.FetchChapters(ctx, chapterMap.Manga.ID)
+	if err != nil {
+		return apperror.Upstream(chapterMap.Provider.ID(), err)
+	}
+
+	var chapter *types.Chapter
+	for i := range chapters {
+		if chapters[i].ID == chapterID {
+			chapter = &chapters[i]
+			break
+		}
+	}
+	if chapter == nil {
+		return apperror.NotFound(chapterMap.Provider.ID(), "Chapter %s not found", chapterID)
+	}
+
+	pages, err := chapterMap.Provider.FetchPages(ctx, chapter.ID)
+	if err != nil {
+		return apperror.Upstream(chapterMap.Provider.ID(), err)
+	}
+
+	c.Set("Content-Type", "text/html")
+	if err := view.Reader(info, chapter, pages).Render(c.Context(), c.Response().BodyWriter()); err != nil {
+		return fmt.Errorf("rendering reader view: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/types"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Characters lists the characters of the anime ':id' with their roles and
+// voice actors. 'language' (e.g. JAPANESE, ENGLISH) keeps one language's
+// voice actors; every language is listed by default.
+func (provider *BaseController) Characters(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 10)
+	if err != nil {
+		return err
+	}
+
+	var language types.StaffLanguage
+	if value := c.Query("language"); value != "" {
+		language = types.StaffLanguage(strings.ToUpper(value))
+		valid := false
+		for _, known := range types.StaffLanguages {
+			valid = valid || language == known
+		}
+		if !valid {
+			return apperror.BadRequest("Invalid 'language' parameter: " + value)
+		}
+	}
+
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+
+	people := provider.registry.People()
+	if people == nil {
+		return errNoPeopleProvider
+	}
+
+	result, err := people.Characters(c.UserContext(), id, language, page, perPage)
+	if err != nil {
+		return apperror.Upstream(people.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// Staff lists the staff credited on the anime ':id'.
+func (provider *BaseController) Staff(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 25)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+
+	people := provider.registry.People()
+	if people == nil {
+		return errNoPeopleProvider
+	}
+
+	result, err := people.Staff(c.UserContext(), id, page, perPage)
+	if err != nil {
+		return apperror.Upstream(people.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// GetCharacter describes the character ':id' with a page of the works they
+// appear in.
+func (provider *BaseController) GetCharacter(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 25)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+
+	people := provider.registry.People()
+	if people == nil {
+		return errNoPeopleProvider
+	}
+
+	result, err := people.GetCharacter(c.UserContext(), id, page, perPage)
+	if err != nil {
+		return apperror.Upstream(people.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// GetStaff describes the staff member ':id' with a page of their works and,
+// for voice actors, of the characters they played.
+func (provider *BaseController) GetStaff(c *fiber.Ctx) error {
+	page, perPage, err := pageParams(c, 25)
+	if err != nil {
+		return err
+	}
+
+	id, err := idParam(c)
+	if err != nil {
+		return err
+	}
+
+	people := provider.registry.People()
+	if people == nil {
+		return errNoPeopleProvider
+	}
+
+	result, err := people.GetStaff(c.UserContext(), id, page, perPage)
+	if err != nil {
+		return apperror.Upstream(people.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// idParam returns the numeric ':id' route parameter.
+func idParam(c *fiber.Ctx) (string, error) {
+	id := c.Params("id")
+	if _, err := strconv.Atoi(id); err != nil {
+		return "", apperror.BadRequest("Invalid id: " + id)
+	}
+	return id, nil
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// providerInfo describes a registered provider and the roles it fulfils.
+type providerInfo struct {
+	ID                 string   `json:"id"`
+	URL                string   `json:"url"`
+	Formats            []string `json:"formats"`
+	NeedsProxy         bool     `json:"needsProxy"`
+	UseGoogleTranslate bool     `json:"useGoogleTranslate"`
+	Roles              []string `json:"roles"`
+}
+
+// ListProviders returns every registered provider in order of preference.
+func (provider *BaseController) ListProviders(c *fiber.Ctx) error {
+	roles := make(map[string][]string)
+	for _, p := range provider.registry.MetaProviders() {
+		roles[p.ID()] = append(roles[p.ID()], "meta")
+	}
+	for _, p := range provider.registry.EpisodeProviders() {
+		roles[p.ID()] = append(roles[p.ID()], "episodes")
+	}
+	for _, p := range provider.registry.SourceProviders() {
+		roles[p.ID()] = append(roles[p.ID()], "sources")
+	}
+	for _, p := range provider.registry.Trackers() {
+		roles[p.ID()] = append(roles[p.ID()], "tracker")
+	}
+
+	var result []providerInfo
+	for _, p := range provider.registry.All() {
+		formats := make([]string, 0, len(p.Formats()))
+		for _, format := range p.Formats() {
+			formats = append(formats, string(format))
+		}
+
+		result = append(result, providerInfo{
+			ID:                 p.ID(),
+			URL:                p.URL(),
+			Formats:            formats,
+			NeedsProxy:         p.NeedsProxy(),
+			UseGoogleTranslate: p.UseGoogleTranslate(),
+			Roles:              roles[p.ID()],
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/proxy"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProxyPlaylist fetches an upstream m3u8 playlist with the headers of its
+// source and rewrites every URI in it to go back through the proxy.
+func (provider *BaseController) ProxyPlaylist(c *fiber.Ctx) error {
+	target, err := provider.proxyTarget(c, proxy.KindPlaylist)
+	if err != nil {
+		return err
+	}
+
+	resp, err := provider.client.Get(c.UserContext(), target.URL, target.Headers)
+	if err != nil {
+		return apperror.Upstream("proxy", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusOK {
+		return apperror.FromStatus("proxy", resp.StatusCode)
+	}
+
+	playlist, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return apperror.Upstream("proxy", fmt.Errorf("reading playlist: %w", err))
+	}
+
+	// Relative URIs resolve against the final URL after redirects.
+	rewritten, err := provider.proxy.RewritePlaylist(playlist, resp.Request.URL, target.Headers)
+	if err != nil {
+		return apperror.Upstream("proxy", fmt.Errorf("rewriting playlist: %w", err))
+	}
+
+	c.Set(fiber.HeaderContentType, "application/vnd.apple.mpegurl")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.Send(rewritten)
+}
+
+// ProxySegment streams a media segment, key or subtitle file through without
+// buffering it, forwarding Range requests.
+func (provider *BaseController) ProxySegment(c *fiber.Ctx) error {
+	target, err := provider.proxyTarget(c, proxy.KindSegment)
+	if err != nil {
+		return err
+	}
+
+	headers := make(map[string]string, len(target.Headers)+1)
+	for key, value := range target.Headers {
+		headers[key] = value
+	}
+	if rangeHeader := c.Get(fiber.HeaderRange); rangeHeader != "" {
+		headers[fiber.HeaderRange] = rangeHeader
+	}
+
+	// The body is streamed after the handler returns, when the request
+	// deadline has already been cancelled, and may take as long as the client
+	// needs to read it. Only the wait for the upstream response is bounded.
+	resp, err := provider.streaming.Get(context.Background(), target.URL, headers)
+	if err != nil {
+		return apperror.Upstream("proxy", err)
+	}
+
+	if resp.StatusCode != fiber.StatusOK && resp.StatusCode != fiber.StatusPartialContent {
+		resp.Body.Close()
+		return apperror.FromStatus("proxy", resp.StatusCode)
+	}
+
+	for _, header := range []string{fiber.HeaderContentType, fiber.HeaderContentRange, fiber.HeaderAcceptRanges} {
+		if value := resp.Header.Get(header); value != "" {
+			c.Set(header, value)
+		}
+	}
+
+	c.Status(resp.StatusCode)
+	return c.SendStream(resp.Body, int(resp.ContentLength))
+}
+
+// proxyTarget verifies the signed url/h/sig parameters of a proxy request.
+func (provider *BaseController) proxyTarget(c *fiber.Ctx, kind string) (*proxy.Target, error) {
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return nil, apperror.BadRequest("Invalid proxy query string.")
+	}
+
+	target, err := provider.proxy.Verify(kind, query)
+	if err != nil {
+		return nil, apperror.Forbidden("", "Invalid or expired proxy link.")
+	}
+	return target, nil
+}
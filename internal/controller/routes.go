@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Routes registers every route of the server on app. The JSON API is served
+// under /v1; the unversioned routes stay as aliases so existing clients keep
+// working. Each route must be documented in internal/openapi, whose tests
+// build this table.
+func (provider *BaseController) Routes(app *fiber.App) {
+	v1 := app.Group("/v1")
+	provider.apiRoutes(v1)
+	provider.apiRoutes(app)
+	v1.Get("/episodes", provider.GetEpisodes)
+	v1.Get("/sources", provider.GetSources)
+	v1.Get("/servers", provider.GetServers)
+
+	app.Get("/watch", provider.WatchEpisode)
+	app.Get("/manga/read", provider.ReadChapter)
+	app.Get("/auth/:tracker/login", provider.Login)
+	app.Get("/auth/:tracker/callback", provider.Callback)
+	app.Post("/auth/:tracker/logout", provider.Logout)
+	app.Get("/graphql", provider.GraphQL)
+	app.Post("/graphql", provider.GraphQL)
+	app.Get("/proxy/m3u8", provider.ProxyPlaylist)
+	app.Get("/proxy/segment", provider.ProxySegment)
+	app.Get("/openapi.json", provider.OpenAPI)
+	app.Get("/docs", provider.Docs)
+
+	admin := app.Group("/admin", provider.RequireAdmin)
+	admin.Get("/mappings/:anilistId", provider.GetMapping)
+	admin.Put("/mappings/:anilistId", provider.PutMapping)
+	admin.Delete("/mappings/:anilistId", provider.DeleteMapping)
+}
+
+// apiRoutes registers the JSON API routes on router.
+func (provider *BaseController) apiRoutes(router fiber.Router) {
+	router.Get("/search", provider.Search)
+	router.Get("/info", provider.GetAnimeInfo)
+	router.Post("/info/batch", provider.GetAnimeInfoBatch)
+	router.Get("/info/:id/characters", provider.Characters)
+	router.Get("/info/:id/staff", provider.Staff)
+	router.Get("/character/:id", provider.GetCharacter)
+	router.Get("/staff/:id", provider.GetStaff)
+	router.Get("/trending", provider.Trending)
+	router.Get("/popular", provider.Popular)
+	router.Get("/seasonal", provider.Seasonal)
+	router.Get("/upcoming", provider.Upcoming)
+	router.Get("/schedule", provider.Schedule)
+	router.Get("/manga/search", provider.SearchManga)
+	router.Get("/manga/info", provider.GetMangaInfo)
+	router.Get("/me/list", provider.MyList)
+	router.Patch("/me/list/:id", provider.UpdateListEntry)
+	router.Post("/me/progress", provider.SaveProgress)
+	router.Get("/providers", provider.ListProviders)
+	router.Get("/cache/stats", provider.CacheStats)
+}
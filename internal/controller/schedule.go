@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"aniverse/internal/apperror"
+	"aniverse/internal/types"
+	"time"
+	_ "time/tzdata" // Timezones work even where the OS has no zoneinfo.
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxScheduleDays bounds the date range of a single /schedule request.
+const maxScheduleDays = 14
+
+// scheduleResponse is the airing timetable grouped by day. Truncated is set
+// when the range holds more broadcasts than the provider returns at once.
+type scheduleResponse struct {
+	Timezone  string              `json:"timezone"`
+	Days      []types.ScheduleDay `json:"days"`
+	Truncated bool                `json:"truncated"`
+}
+
+// Schedule returns the broadcasts between the 'from' and 'to' dates
+// (YYYY-MM-DD, inclusive, defaulting to the coming week), grouped by day in
+// the 'tz' timezone (an IANA name, defaulting to UTC).
+func (provider *BaseController) Schedule(c *fiber.Ctx) error {
+	location, err := time.LoadLocation(c.Query("tz", "UTC"))
+	if err != nil {
+		return apperror.BadRequest("Invalid 'tz' parameter. It should be an IANA timezone such as Europe/Berlin.")
+	}
+
+	now := time.Now().In(location)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, value, location); err != nil {
+			return apperror.BadRequest("Invalid 'from' parameter. It should be a date such as 2026-10-17.")
+		}
+	}
+
+	to := from.AddDate(0, 0, 6)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, value, location); err != nil {
+			return apperror.BadRequest("Invalid 'to' parameter. It should be a date such as 2026-10-24.")
+		}
+	}
+
+	end := to.AddDate(0, 0, 1)
+	if !end.After(from) || end.Sub(from) > maxScheduleDays*24*time.Hour+time.Hour {
+		return apperror.BadRequest("'to' must not be before 'from', and the range may span at most 14 days.")
+	}
+
+	discovery := provider.registry.Discovery()
+	if discovery == nil {
+		return errNoDiscoveryProvider
+	}
+
+	schedule, err := discovery.Schedule(c.UserContext(), from, end)
+	if err != nil {
+		return apperror.Upstream(discovery.ID(), err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(scheduleResponse{
+		Timezone:  location.String(),
+		Days:      groupByDay(schedule.Entries, from, end, location),
+		Truncated: schedule.Truncated,
+	})
+}
+
+// groupByDay buckets entries by their local airing date. Every day of the
+// range is present, even when nothing airs.
+func groupByDay(entries []types.ScheduleEntry, from time.Time, end time.Time, location *time.Location) []types.ScheduleDay {
+	var days []types.ScheduleDay
+	index := make(map[string]int)
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		index[date] = len(days)
+		days = append(days, types.ScheduleDay{
+			Date:    date,
+			Weekday: day.Weekday().String(),
+			Entries: []types.ScheduleEntry{},
+		})
+	}
+
+	for _, entry := range entries {
+		date := time.Unix(entry.AiringAt, 0).In(location).Format(time.DateOnly)
+		if i, ok := index[date]; ok {
+			days[i].Entries = append(days[i].Entries, entry)
+		}
+	}
+	return days
+}
@@ -1,73 +1,187 @@
 package controller
 
 import (
-	"aniverse/internal/mapping"
+	"aniverse/internal/apperror"
 	"aniverse/internal/types"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// Search lists anime matching 'q' and the optional filters read by
+// searchFilter. A numeric 'q' is looked up as an AniList ID instead.
 func (provider *BaseController) Search(c *fiber.Ctx) error {
 	query := c.Query("q")
-	if query == "" {
-		return c.Status(fiber.StatusBadRequest).SendString("Missing 'q' parameter.")
-	}
-
-	// Optional
-	pageParam := c.Query("page")
-	perPageParam := c.Query("per_page")
 
-	page := 1
-	perPage := 10
-
-	if pageParam != "" {
-		if p, err := strconv.Atoi(pageParam); err != nil {
-			page = p
-		}
+	meta := provider.registry.Meta()
+	if meta == nil {
+		return errNoMetaProvider
 	}
 
-	if perPageParam != "" {
-		if pp, err := strconv.Atoi(perPageParam); err == nil {
-			perPage = pp
-		}
+	filter, err := searchFilter(c, meta.Formats())
+	if err != nil {
+		return err
 	}
+	if query == "" && isEmptyFilter(filter) {
+		return apperror.BadRequest("Missing 'q' parameter or filter.")
+	}
+	filter.Search = query
 
 	// Check if the query is a numeric ID
 	if id, err := strconv.Atoi(query); err == nil {
 		// Query is numeric, search by ID
-		result, err := provider.anilist.GetMedia(fmt.Sprintf("%d", id))
+		result, err := meta.GetMedia(c.UserContext(), fmt.Sprintf("%d", id))
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString("Error retrieving anime by ID: " + err.Error())
+			return apperror.Upstream(meta.ID(), err)
 		}
 		return c.Status(fiber.StatusOK).JSON(result)
 	}
 
-	results, err := provider.anilist.Search(query, types.TypeAnime, provider.anilist.Formats(), page, perPage)
+	results, err := meta.Search(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return apperror.Upstream(meta.ID(), err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(&results)
+	return c.Status(fiber.StatusOK).JSON(results)
+}
+
+// searchFilter reads the search filters from the query string. List
+// parameters are comma-separated:
+//
+//	genres, exclude_genres, tags, exclude_tags, format (e.g. TV,MOVIE), sort (e.g. SCORE_DESC)
+//	year, season, status, country (e.g. JP), min_score (0-10), min_episodes, max_episodes
+//	page, per_page
+//
+// Only the given formats are accepted for 'format'.
+func searchFilter(c *fiber.Ctx, formats []types.Format) (types.MediaFilter, error) {
+	var filter types.MediaFilter
+
+	page, perPage, err := pageParams(c, 10)
+	if err != nil {
+		return filter, err
+	}
+	filter.Page = page
+	filter.PerPage = perPage
+
+	filter.Genres = listParam(c, "genres")
+	filter.ExcludedGenres = listParam(c, "exclude_genres")
+	filter.Tags = listParam(c, "tags")
+	filter.ExcludedTags = listParam(c, "exclude_tags")
+	filter.CountryOfOrigin = strings.ToUpper(c.Query("country"))
+
+	for _, value := range listParam(c, "format") {
+		format := types.Format(strings.ToUpper(value))
+		valid := false
+		for _, known := range formats {
+			valid = valid || format == known
+		}
+		if !valid {
+			return filter, apperror.BadRequest("Invalid 'format' value: " + value)
+		}
+		filter.Formats = append(filter.Formats, format)
+	}
+
+	for _, value := range listParam(c, "sort") {
+		sort := types.MediaSort(strings.ToUpper(value))
+		valid := false
+		for _, known := range types.MediaSorts {
+			valid = valid || sort == known
+		}
+		if !valid {
+			return filter, apperror.BadRequest("Invalid 'sort' value: " + value)
+		}
+		filter.Sort = append(filter.Sort, sort)
+	}
+
+	if value := c.Query("season"); value != "" {
+		filter.Season = types.Season(strings.ToUpper(value))
+		switch filter.Season {
+		case types.SeasonWinter, types.SeasonSpring, types.SeasonSummer, types.SeasonFall:
+		default:
+			return filter, apperror.BadRequest("Invalid 'season' parameter. It should be WINTER, SPRING, SUMMER or FALL.")
+		}
+	}
+
+	if value := c.Query("status"); value != "" {
+		filter.Status = types.MediaStatus(strings.ToUpper(value))
+		switch filter.Status {
+		case types.StatusFinished, types.StatusReleasing, types.StatusNotYetReleased, types.StatusCancelled:
+		default:
+			return filter, apperror.BadRequest("Invalid 'status' parameter. It should be FINISHED, RELEASING, NOT_YET_RELEASED or CANCELLED.")
+		}
+	}
+
+	if value := c.Query("min_score"); value != "" {
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil || score < 0 || score > 10 {
+			return filter, apperror.BadRequest("Invalid 'min_score' parameter. It should be between 0 and 10.")
+		}
+		filter.MinScore = score
+	}
+
+	for name, target := range map[string]*int{
+		"year":         &filter.Year,
+		"min_episodes": &filter.MinEpisodes,
+		"max_episodes": &filter.MaxEpisodes,
+	} {
+		if value := c.Query(name); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 1 {
+				return filter, apperror.BadRequest("Invalid '" + name + "' parameter. It should be a positive integer.")
+			}
+			*target = number
+		}
+	}
+	if filter.MaxEpisodes > 0 && filter.MinEpisodes > filter.MaxEpisodes {
+		return filter, apperror.BadRequest("'min_episodes' must not exceed 'max_episodes'.")
+	}
+
+	return filter, nil
+}
+
+// isEmptyFilter reports whether filter narrows nothing beyond pagination.
+func isEmptyFilter(filter types.MediaFilter) bool {
+	filter.Page, filter.PerPage = 0, 0
+	return reflect.DeepEqual(filter, types.MediaFilter{})
+}
+
+// listParam splits a comma-separated query parameter, dropping empty items.
+func listParam(c *fiber.Ctx, name string) []string {
+	var values []string
+	for _, value := range strings.Split(c.Query(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
 }
 
 func (provider *BaseController) GetAnimeInfo(c *fiber.Ctx) error {
 	// Get the 'id' parameter from the route
 	id := c.Query("id")
 	if id == "" {
-		return c.Status(fiber.StatusBadRequest).SendString("Missing 'id' parameter")
+		return apperror.BadRequest("Missing 'id' parameter.")
 	}
 
-	// Fetch anime info from AniList using the provided AniListBase client
-	info, err := provider.anilist.GetMedia(id)
+	meta := provider.registry.Meta()
+	if meta == nil {
+		return errNoMetaProvider
+	}
+
+	// Fetch anime info from the metadata provider (AniList)
+	info, err := meta.GetMedia(c.UserContext(), id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error fetching anime info: " + err.Error())
+		return apperror.Upstream(meta.ID(), err)
 	}
-	// Fetch episodes from GogoAnime (or another provider)
-	episodesResult, err := mapping.GetEpisodes(id) // GetEpisodes returns *EpisodesResult
+	// Fetch episodes from the first episode provider (GogoAnime) that carries the anime
+	episodesResult, err := provider.mapper.GetEpisodes(c.UserContext(), id) // GetEpisodes returns *EpisodesResult
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString("Error fetching episodes: " + err.Error())
+		return err
 	}
 
 	// Make sure the episodes are correctly assigned
@@ -78,21 +192,89 @@ func (provider *BaseController) GetAnimeInfo(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(info)
 }
 
-func (provider *BaseController) SearchGogoAnime(c *fiber.Ctx) error {
+// maxBatchIDs bounds how many IDs one GetAnimeInfoBatch request may ask for.
+const maxBatchIDs = 100
+
+// batchResult is one entry of a GetAnimeInfoBatch response.
+type batchResult struct {
+	ID    string           `json:"id"`
+	Media *types.AnimeInfo `json:"media,omitempty"`
+	Error *errorDetail     `json:"error,omitempty"`
+}
+
+// GetAnimeInfoBatch returns the anime of the AniList IDs in the JSON body,
+// {"ids": [21, "1535"]}, in order. Each ID that fails carries its own error
+// instead of failing the request.
+func (provider *BaseController) GetAnimeInfoBatch(c *fiber.Ctx) error {
+	var body struct {
+		IDs []json.Number `json:"ids"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return apperror.BadRequest("Invalid JSON body. Expected {\"ids\": [...]} with numeric IDs.")
+	}
+	if len(body.IDs) == 0 {
+		return apperror.BadRequest("Missing 'ids'.")
+	}
+	if len(body.IDs) > maxBatchIDs {
+		return apperror.BadRequest("Too many 'ids'. At most " + strconv.Itoa(maxBatchIDs) + " are allowed.")
+	}
+
+	ids := make([]string, len(body.IDs))
+	for i, id := range body.IDs {
+		ids[i] = id.String()
+	}
+
+	meta := provider.registry.BatchMeta()
+	if meta == nil {
+		return errNoMetaProvider
+	}
+
+	results := meta.GetMediaBatch(c.UserContext(), ids)
+	response := make([]batchResult, len(results))
+	for i, result := range results {
+		response[i] = batchResult{ID: result.ID, Media: result.Media}
+		if result.Err != nil {
+			_, detail := classify(c, apperror.Upstream(meta.ID(), result.Err))
+			detail.RequestID = ""
+			response[i].Error = &detail
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"results": response})
+}
+
+// SearchProvider searches an episode provider directly, selected with the
+// 'provider' parameter and defaulting to the preferred one.
+func (provider *BaseController) SearchProvider(c *fiber.Ctx) error {
 	query := c.Query("q")
 	if query == "" {
-		return c.Status(fiber.StatusBadRequest).SendString("Missing 'q' parameter")
+		return apperror.BadRequest("Missing 'q' parameter.")
+	}
+
+	episodeProviders := provider.registry.EpisodeProviders()
+	if len(episodeProviders) == 0 {
+		return errNoEpisodeProvider
+	}
+
+	target := episodeProviders[0]
+	if id := c.Query("provider"); id != "" {
+		p, ok := provider.registry.EpisodeProvider(id)
+		if !ok {
+			return apperror.NotFound("", "Unknown provider: %s", id)
+		}
+		target = p
 	}
 
-	results, err := provider.gogoanime.Search(query)
+	results, err := target.Search(c.UserContext(), query)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		return apperror.Upstream(target.ID(), err)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(results)
 }
 
-// mergeEpisodes merges episodes fetched from AniList and GogoAnime (or other providers).
+// mergeEpisodes merges episodes fetched from AniList and GogoAnime (or other
+// providers), ordered by episode number.
 func mergeEpisodes(animeListEpisodes, providerEpisodes []types.Episode) []types.Episode {
 	// Create a map to combine episodes by episode number
 	episodeMap := make(map[int]types.Episode)
@@ -105,9 +287,14 @@ func mergeEpisodes(animeListEpisodes, providerEpisodes []types.Episode) []types.
 	// Merge episodes from the provider, adding sources where applicable
 	for _, ep := range providerEpisodes {
 		if existingEp, found := episodeMap[ep.Number]; found {
-			// If the episode exists, update the source and dub information
+			// If the episode exists, update the source and sub/dub information
+			existingEp.HasSub = ep.HasSub
 			existingEp.HasDub = ep.HasDub
 			existingEp.Source = ep.Source // Replace or merge source info as needed
+			// Prefer the provider's title, which the mapper takes from MAL
+			if ep.EpisodeTitle != "" {
+				existingEp.EpisodeTitle = ep.EpisodeTitle
+			}
 			episodeMap[ep.Number] = existingEp
 		} else {
 			// If not found, add the episode directly
@@ -115,11 +302,14 @@ func mergeEpisodes(animeListEpisodes, providerEpisodes []types.Episode) []types.
 		}
 	}
 
-	// Convert the map back to a slice
+	// Convert the map back to a slice, in episode order
 	var mergedEpisodes []types.Episode
 	for _, ep := range episodeMap {
 		mergedEpisodes = append(mergedEpisodes, ep)
 	}
+	sort.Slice(mergedEpisodes, func(i, j int) bool {
+		return mergedEpisodes[i].Number < mergedEpisodes[j].Number
+	})
 
 	return mergedEpisodes
 }
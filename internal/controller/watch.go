@@ -1,120 +1,128 @@
 package controller
 
 import (
+	"aniverse/internal/apperror"
 	"aniverse/internal/mapping"
+	"aniverse/internal/provider"
 	"aniverse/internal/types"
 	"aniverse/view"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/url"
 	"strconv"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// WatchEpisode renders the player for episode 'ep' of the anime 'id' (see
+// sourceParams).
 func (provider *BaseController) WatchEpisode(c *fiber.Ctx) error {
-	animeID := c.Query("id")
-	episodeNumStr := c.Query("ep")
-
-	if animeID == "" || episodeNumStr == "" {
-		return c.Status(fiber.StatusBadRequest).SendString("Missing 'id' or 'ep' query parameter")
+	targetEpisode, err := provider.watchEpisode(c)
+	if err != nil {
+		return err
 	}
 
-	episodeNum, err := strconv.Atoi(episodeNumStr)
-	if err != nil || episodeNum < 1 {
-		return c.Status(fiber.StatusBadRequest).SendString("Invalid 'ep' parameter. It should be a positive integer.")
+	// Set headers and render the view
+	c.Set("Content-Type", "text/html")
+	if err := view.Watch(targetEpisode).Render(c.Context(), c.Response().BodyWriter()); err != nil {
+		return fmt.Errorf("rendering watch view: %w", err)
 	}
+	return nil
+}
 
-	// Map AniList ID to GogoAnime IDs
-	mappingResult, err := mapping.GetGogoAnimeMap(animeID)
+// GetSources returns what WatchEpisode plays as JSON: the episode with its
+// streams in 'source'.
+func (provider *BaseController) GetSources(c *fiber.Ctx) error {
+	targetEpisode, err := provider.watchEpisode(c)
 	if err != nil {
-		log.Printf("Error mapping AniList ID %s: %v", animeID, err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to map AniList ID to GogoAnime IDs.")
+		return err
 	}
+	return c.Status(fiber.StatusOK).JSON(targetEpisode)
+}
 
-	// Choose Subbed or Dubbed version (defaulting to Subbed)
-	var gogoAnimeID string
-	version := "sub" // default
+// episodeRequest is an episode asked for by WatchEpisode or GetSources.
+type episodeRequest struct {
+	animeID    string
+	episode    int
+	dub        bool
+	server     types.StreamingServer
+	forceProxy *bool
+}
 
-	if mappingResult.Sub != nil {
-		gogoAnimeID = mappingResult.Sub.ID
-	} else if mappingResult.Dub != nil {
-		gogoAnimeID = mappingResult.Dub.ID
-		version = "dub"
-	} else {
-		return c.Status(fiber.StatusNotFound).SendString("No GogoAnime mapping found for this anime.")
+// episodeParams reads the AniList ID 'id', the episode number 'ep' and 'dub'
+// (true for the dubbed version).
+func episodeParams(c *fiber.Ctx) (episodeRequest, error) {
+	request := episodeRequest{
+		animeID: c.Query("id"),
 	}
+	episodeNumStr := c.Query("ep")
 
-	log.Printf("Selected GogoAnime ID: %s (Version: %s)", gogoAnimeID, version)
-
-	// Fetch episodes for the selected GogoAnime ID
-	episodes, err := provider.gogoanime.FetchEpisodes("/category/" + gogoAnimeID)
-	if err != nil {
-		log.Printf("Error fetching episodes for GogoAnime ID %s: %v", gogoAnimeID, err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to fetch episodes.")
+	if request.animeID == "" || episodeNumStr == "" {
+		return request, apperror.BadRequest("Missing 'id' or 'ep' query parameter.")
 	}
 
-	// Find the episode with the specified episode number
-	var targetEpisode types.Episode
-	found := false
-	for _, ep := range episodes {
-		if ep.Number == episodeNum {
-			targetEpisode = ep
-			found = true
-			break
-		}
+	episodeNum, err := strconv.Atoi(episodeNumStr)
+	if err != nil || episodeNum < 1 {
+		return request, apperror.BadRequest("Invalid 'ep' parameter. It should be a positive integer.")
 	}
+	request.episode = episodeNum
 
-	if !found {
-		return c.Status(fiber.StatusNotFound).SendString(fmt.Sprintf("Episode number %d not found.", episodeNum))
+	if value := c.Query("dub"); value != "" {
+		if request.dub, err = strconv.ParseBool(value); err != nil {
+			return request, apperror.BadRequest("Invalid 'dub' parameter. It should be true or false.")
+		}
 	}
 
-	log.Printf("Found Episode: %s (Number: %d)", targetEpisode.ID, targetEpisode.Number)
-
-	baseURL := strings.TrimSpace(provider.gogoanime.URL())
-	epID := strings.TrimSpace(targetEpisode.ID)
+	return request, nil
+}
 
-	parsedBase, err := url.Parse(baseURL)
+// sourceParams reads the episodeParams and how to stream the episode:
+// 'server', one of the names GetServers lists, and 'proxy', which overrides
+// whether streams go through the HLS proxy.
+func sourceParams(c *fiber.Ctx) (episodeRequest, error) {
+	request, err := episodeParams(c)
 	if err != nil {
-		log.Printf("Error parsing base URL: %v", err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Internal Server Error")
+		return request, err
 	}
+	request.server = types.StreamingServer(c.Query("server"))
 
-	parsedEpisode, err := url.Parse(epID)
-	if err != nil {
-		log.Printf("Error parsing episode ID: %v", err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Internal Server Error")
+	if value := c.Query("proxy"); value != "" {
+		useProxy, err := strconv.ParseBool(value)
+		if err != nil {
+			return request, apperror.BadRequest("Invalid 'proxy' parameter. It should be true or false.")
+		}
+		request.forceProxy = &useProxy
 	}
 
-	// Construct the GogoAnime episode URL
-	episodeURL := parsedBase.ResolveReference(parsedEpisode).String()
-
-	log.Printf("Constructed Episode URL: %s", episodeURL)
+	return request, nil
+}
 
-	// Fetch the streaming link from the episode page
-	streamingLink, err := provider.gogoanime.GetSource(episodeURL)
+// watchEpisode resolves the episode requested by sourceParams with its
+// streams and titles it from AniList and MAL. Progress is saved separately,
+// by the player, through SaveProgress.
+func (provider *BaseController) watchEpisode(c *fiber.Ctx) (*types.Episode, error) {
+	request, err := sourceParams(c)
 	if err != nil {
-		log.Printf("Error getting streaming link for URL %s: %v", episodeURL, err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to retrieve streaming link.")
+		return nil, err
 	}
+	animeID, episodeNum := request.animeID, request.episode
 
-	log.Printf("Streaming Link: %s", streamingLink)
+	ctx := c.UserContext()
 
-	// Use the extractor to get the video source
-	source, err := provider.extractor.Extract(streamingLink)
+	targetEpisode, version, err := provider.episodeSource(ctx, request)
 	if err != nil {
-		log.Printf("Error extracting video sources from streaming link %s: %v", streamingLink, err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to extract video sources.")
+		return nil, err
 	}
 
-	// Assign extracted source to the Episode's Source field.
-	targetEpisode.Source = *source
+	meta := provider.registry.Meta()
+	if meta == nil {
+		return nil, errNoMetaProvider
+	}
 
-	animeInfo, err := provider.anilist.GetMedia(animeID)
+	animeInfo, err := meta.GetMedia(ctx, animeID)
 	if err != nil {
-		log.Printf("Error fetching data from Anilist for ID %s: %v", animeID, err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to fetch anime data.")
+		return nil, apperror.Upstream(meta.ID(), err)
 	}
 
 	targetEpisode.ID = animeInfo.ID
@@ -122,7 +130,7 @@ func (provider *BaseController) WatchEpisode(c *fiber.Ctx) error {
 
 	// Fetch episode titles from MAL if available (using idMal)
 	if animeInfo.IDMal != "" {
-		malEpisodes, err := provider.myanimelist.GetEpisodeTitles(animeInfo.IDMal, animeInfo.Title.English, episodeNum)
+		malEpisodes, err := provider.myanimelist.GetEpisodeTitles(ctx, animeInfo.IDMal, animeInfo.Title.English, episodeNum)
 		if err != nil {
 			log.Printf("Error fetching episode titles from MyAnimeList for ID %s: %v", animeInfo.IDMal, err)
 		} else {
@@ -134,21 +142,133 @@ func (provider *BaseController) WatchEpisode(c *fiber.Ctx) error {
 	}
 
 	if targetEpisode.Source.Headers == nil {
-		source.Headers = make(map[string]string)
+		targetEpisode.Source.Headers = make(map[string]string)
 	}
 
 	targetEpisode.Source.Headers["Version"] = version
 
-	log.Printf("Video Source Extracted: %+v", source)
+	log.Printf("Video Source Extracted: %+v", targetEpisode.Source)
+	return targetEpisode, nil
+}
 
-	// Set headers and render the view
-	c.Set("Content-Type", "text/html")
-	if err := view.Watch(&targetEpisode).Render(c.Context(), c.Response().BodyWriter()); err != nil {
-		log.Printf("Error rendering view: %v", err)
-		return c.Status(fiber.StatusInternalServerError).SendString("Failed to render view.")
+// GetServers lists the servers the episode provider offers for episode 'ep'
+// of the anime 'id' ('dub=true' for the dubbed version), each with the name to
+// pass as 'server' to GetSources and its embed URL.
+func (provider *BaseController) GetServers(c *fiber.Ctx) error {
+	request, err := episodeParams(c)
+	if err != nil {
+		return err
 	}
 
-	// Just to verify JSON DATA
-	// return c.Status(200).JSON(targetEpisode)
-	return nil
+	targetEpisode, sourceProvider, _, err := provider.providerEpisode(c.UserContext(), request)
+	if err != nil {
+		return err
+	}
+
+	servers, err := sourceProvider.GetServers(c.UserContext(), targetEpisode.ID)
+	if err != nil {
+		return apperror.Upstream(sourceProvider.ID(), err)
+	}
+	return c.Status(fiber.StatusOK).JSON(servers)
+}
+
+// episodeSource finds the requested episode with providerEpisode and extracts
+// its stream from request.server, or the provider's default server when it
+// is empty. The version picked ("sub" or "dub") is returned with the episode,
+// whose ID is the provider's. request.forceProxy overrides whether the streams
+// go through the HLS proxy.
+func (provider *BaseController) episodeSource(ctx context.Context, request episodeRequest) (*types.Episode, string, error) {
+	targetEpisode, sourceProvider, version, err := provider.providerEpisode(ctx, request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Fetch the streaming link from the episode page
+	streamingLink, err := sourceProvider.GetSource(ctx, targetEpisode.ID, request.server)
+	if err != nil {
+		return nil, "", apperror.Upstream(sourceProvider.ID(), err)
+	}
+
+	log.Printf("Streaming Link: %s", streamingLink)
+
+	// Use the extractor of the embed host to get the video source
+	source, err := provider.extractors.Extract(ctx, streamingLink)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Route the streams through our HLS proxy when the provider needs it,
+	// unless the caller decides otherwise with 'proxy'.
+	forceProxy := request.forceProxy
+	if forceProxy == nil && sourceProvider.NeedsProxy() || forceProxy != nil && *forceProxy {
+		source = provider.proxy.RewriteSource(source)
+	}
+
+	// Assign extracted source to the Episode's Source field.
+	targetEpisode.Source = *source
+	return &targetEpisode, version, nil
+}
+
+// providerEpisode finds episode request.episode of the anime with AniList ID
+// request.animeID on the first episode provider that carries it, along with
+// the source provider that streams it. The subbed version is preferred
+// unless request.dub is set; the version picked ("sub" or "dub") is returned
+// with the episode.
+func (provider *BaseController) providerEpisode(ctx context.Context, request episodeRequest) (types.Episode, provider.SourceProvider, string, error) {
+	animeID, episodeNum := request.animeID, request.episode
+
+	// Map the AniList ID onto the first episode provider that carries it
+	mappingResult, err := provider.mapper.FindMap(ctx, animeID)
+	if errors.Is(err, mapping.ErrNoMapping) {
+		return types.Episode{}, nil, "", apperror.MappingNotFound("", animeID)
+	}
+	if err != nil {
+		return types.Episode{}, nil, "", err
+	}
+	episodeProvider := mappingResult.Provider
+
+	// Choose Subbed or Dubbed version (defaulting to Subbed)
+	var providerAnimeID string
+	version := "sub" // default
+
+	switch {
+	case request.dub && mappingResult.Dub == nil:
+		return types.Episode{}, nil, "", apperror.NotFound(episodeProvider.ID(), "No dub available for %s.", animeID)
+	case !request.dub && mappingResult.Sub != nil:
+		providerAnimeID = mappingResult.Sub.ID
+	default:
+		providerAnimeID = mappingResult.Dub.ID
+		version = "dub"
+	}
+
+	log.Printf("Selected %s ID: %s (Version: %s)", episodeProvider.ID(), providerAnimeID, version)
+
+	// Fetch episodes for the selected provider ID
+	episodes, err := episodeProvider.FetchEpisodes(ctx, providerAnimeID)
+	if err != nil {
+		return types.Episode{}, nil, "", apperror.Upstream(episodeProvider.ID(), err)
+	}
+
+	// Find the episode with the specified episode number
+	var targetEpisode types.Episode
+	found := false
+	for _, ep := range episodes {
+		if ep.Number == episodeNum {
+			targetEpisode = ep
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return types.Episode{}, nil, "", apperror.NotFound(episodeProvider.ID(), "Episode number %d not found.", episodeNum)
+	}
+
+	log.Printf("Found Episode: %s (Number: %d)", targetEpisode.ID, targetEpisode.Number)
+
+	sourceProvider, ok := provider.registry.SourceProvider(episodeProvider.ID())
+	if !ok {
+		return types.Episode{}, nil, "", apperror.NotFound(episodeProvider.ID(), "No source provider available for %s.", episodeProvider.ID())
+	}
+	return targetEpisode, sourceProvider, version, nil
 }
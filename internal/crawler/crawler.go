@@ -1,9 +1,9 @@
 package crawler
 
 import (
-	"fmt"
-	"io"
-	"net/http"
+	"context"
+
+	"aniverse/internal/httpclient"
 
 	"github.com/gocolly/colly/v2"
 )
@@ -19,35 +19,18 @@ type BaseCrawler struct {
 
 func NewBaseCrawler() *BaseCrawler {
 	return &BaseCrawler{
-		Client:    &HttpClient{},
+		Client:    &HttpClient{client: httpclient.Default},
 		Collector: colly.NewCollector(),
 	}
 }
 
-type HttpClient struct{}
-
-func (c *HttpClient) Get(url string, headers map[string]string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP request failed with status %d", resp.StatusCode)
-	}
+// HttpClient fetches pages through the shared outbound client.
+type HttpClient struct {
+	client *httpclient.Client
+}
 
-	return io.ReadAll(resp.Body)
+func (c *HttpClient) Get(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	return c.client.GetBytes(ctx, url, headers)
 }
 
 func (c *BaseCrawler) OnHTML(selector string, callback func(*colly.HTMLElement)) {
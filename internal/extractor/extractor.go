@@ -0,0 +1,93 @@
+package extractor
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/types"
+)
+
+// Common errors used throughout the extractor package. Each carries the
+// apperror kind it is reported to clients as.
+var (
+	ErrInvalidArgument = apperror.New(apperror.KindBadRequest, "", "invalid argument")
+	ErrRequest         = apperror.New(apperror.KindUpstreamUnavailable, "", "request error")
+	ErrJSONParse       = apperror.New(apperror.KindExtractionFailed, "", "JSON parsing error")
+	ErrScraping        = apperror.New(apperror.KindExtractionFailed, "", "scraping error")
+	ErrNoContent       = apperror.New(apperror.KindNotFound, "", "no content found")
+	ErrInvalidRegex    = apperror.New(apperror.KindExtractionFailed, "", "invalid regex")
+	ErrUnsupportedHost = apperror.New(apperror.KindExtractionFailed, "", "unsupported host")
+)
+
+// Extractor pulls the streams out of the embed page of a video host.
+type Extractor interface {
+	// Matches reports whether link is on a host the extractor handles.
+	Matches(link string) bool
+	Extract(ctx context.Context, link string) (*types.Source, error)
+}
+
+// Registry holds the extractors available to controllers, in registration
+// order, and picks the one for an embed link by its host.
+type Registry struct {
+	mu         sync.RWMutex
+	extractors []Extractor
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds e to the registry. Extractors registered first are tried first.
+func (r *Registry) Register(e Extractor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extractors = append(r.extractors, e)
+}
+
+// Get returns the first registered extractor that matches link.
+func (r *Registry) Get(link string) (Extractor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.extractors {
+		if e.Matches(link) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Extract extracts the streams of link with the extractor of its host. Links
+// no extractor matches fail with ErrUnsupportedHost.
+func (r *Registry) Extract(ctx context.Context, link string) (*types.Source, error) {
+	e, ok := r.Get(link)
+	if !ok {
+		host := link
+		if parsed, err := url.Parse(link); err == nil && parsed.Host != "" {
+			host = parsed.Host
+		}
+		return nil, apperror.Wrap(apperror.KindExtractionFailed, "", ErrUnsupportedHost, "No extractor supports "+host+".")
+	}
+	return e.Extract(ctx, link)
+}
+
+// matchesHost reports whether link is on one of hosts or their subdomains.
+// hosts must be lower case.
+func matchesHost(link string, hosts []string) bool {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
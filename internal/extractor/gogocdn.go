@@ -2,39 +2,27 @@ package extractor
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
-	"path"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
 	"aniverse/internal/crawler"
 	"aniverse/internal/types"
 )
 
-// Constants for encryption and decryption keys, as well as the fixed initialization vector.
-const (
-	encryptionKey        = "37911490979715163134003223491201"
-	decryptionKey        = "54674138327930866480207815084989"
-	initializationVector = "3134003223491201"
-)
-
-// Common errors used throughout the extractor package.
-var (
-	ErrInvalidArgument = errors.New("invalid argument")
-	ErrRequest         = errors.New("request error")
-	ErrJSONParse       = errors.New("JSON parsing error")
-	ErrScraping        = errors.New("scraping error")
-	ErrNoContent       = errors.New("no content found")
-	ErrInvalidRegex    = errors.New("invalid regex")
-)
+var _ Extractor = (*Gogocdn)(nil)
 
 // Responsible for handling the decryption of video sources
 // from GogoAnime. It holds the keys, initialization vector (IV), and a base crawler
@@ -45,21 +33,35 @@ type Gogocdn struct {
 	iv              []byte
 	baseCrawler     *crawler.BaseCrawler
 	reEncryptedData *regexp.Regexp
+	cache           cache.Store
+	hosts           []string
 }
 
-// Initializes a new instance of Gogocdn, taking in a BaseCrawler.
-// It uses predefined encryption and decryption keys, as well as a fixed IV.
-func NewGogocdn(c *crawler.BaseCrawler) *Gogocdn {
+// Initializes a new instance of Gogocdn, taking in the configured encryption
+// and decryption keys, IV and embed hosts, a BaseCrawler and the cache store
+// used for extracted sources.
+func NewGogocdn(cfg config.GogocdnConfig, c *crawler.BaseCrawler, store cache.Store) *Gogocdn {
 	baseCrawler := ensureBaseCrawler(c)
+	hosts := make([]string, len(cfg.Hosts))
+	for i, host := range cfg.Hosts {
+		hosts[i] = strings.ToLower(strings.TrimSpace(host))
+	}
 	return &Gogocdn{
-		key:             []byte(encryptionKey),
-		decryptionKey:   []byte(decryptionKey),
-		iv:              []byte(initializationVector),
+		key:             []byte(cfg.EncryptionKey),
+		decryptionKey:   []byte(cfg.DecryptionKey),
+		iv:              []byte(cfg.IV),
 		baseCrawler:     baseCrawler,
 		reEncryptedData: regexp.MustCompile(`data-value="(.+?)"`),
+		cache:           store,
+		hosts:           hosts,
 	}
 }
 
+// Matches reports whether link is on one of the configured embed hosts.
+func (g *Gogocdn) Matches(link string) bool {
+	return matchesHost(link, g.hosts)
+}
+
 // The structure for the JSON data returned by GogoAnime.
 type gogoCdnData struct {
 	Data string `json:"data"`
@@ -81,7 +83,22 @@ type gogoCdn struct {
 
 // Retrieves the streaming sources for a given link. It first
 // parses the page, decrypts the content, and returns a structured response.
-func (g *Gogocdn) Extract(link string) (*types.Source, error) {
+// Errors are typed apperrors attributed to gogocdn.
+func (g *Gogocdn) Extract(ctx context.Context, link string) (*types.Source, error) {
+	source, err := g.extract(ctx, link)
+	if err != nil {
+		return nil, apperror.Upstream("gogocdn", err)
+	}
+	return source, nil
+}
+
+func (g *Gogocdn) extract(ctx context.Context, link string) (*types.Source, error) {
+	cacheKey := cache.Key("gogocdn", "source", link)
+	var cached types.Source
+	if cache.GetJSON(ctx, g.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
 	// Initialize the Source struct with all necessary fields
 	sources := &types.Source{
 		Sources:       []types.Quality{},
@@ -107,20 +124,22 @@ func (g *Gogocdn) Extract(link string) (*types.Source, error) {
 	}
 
 	// Parse and retrieve the encrypted parameters from the page.
-	encryptedParams, err := g.parsePage(link, contentID)
+	encryptedParams, err := g.parsePage(ctx, link, contentID)
 	if err != nil {
 		return nil, fmt.Errorf("Gogocdn Extract: %w", err)
 	}
 
 	// Construct the URL to fetch the encrypted content.
 	nextHost := fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
+	// The CDN only serves playlists and segments to the embed page.
+	sources.Headers["Referer"] = nextHost + "/"
 	apiURL := fmt.Sprintf("%s/encrypt-ajax.php?%s", nextHost, encryptedParams)
 	headers := map[string]string{"X-Requested-With": "XMLHttpRequest"}
 
 	// Send the request to fetch the encrypted video data.
-	response, err := g.baseCrawler.Client.Get(apiURL, headers)
+	response, err := g.baseCrawler.Client.Get(ctx, apiURL, headers)
 	if err != nil {
-		return nil, fmt.Errorf("Gogocdn Extract: %w : %s", ErrRequest, err.Error())
+		return nil, fmt.Errorf("Gogocdn Extract: %w : %w", ErrRequest, err)
 	}
 
 	// Unmarshal the JSON response into gogoCdnData structure.
@@ -143,19 +162,24 @@ func (g *Gogocdn) Extract(link string) (*types.Source, error) {
 		return nil, fmt.Errorf("Gogocdn Extract: %w : %s", ErrJSONParse, err.Error())
 	}
 
+	// Signed URLs bound how long the extracted source can be cached.
+	var signedURLs []string
+
 	// Iterate over the primary sources to extract the master m3u8 URL
 	for _, s := range dataFile.Source {
 		if s.File == "" {
 			continue
 		}
+		signedURLs = append(signedURLs, s.File)
+
 		// Extract intro/outro from the first M3U8 file
-		introEnd, outroStart, err := g.calculateIntroOutro(s.File)
+		introEnd, outroStart, err := g.calculateIntroOutro(ctx, s.File)
 		if err != nil {
 			return nil, fmt.Errorf("Gogocdn Extract: failed to extract intro/outro: %w", err)
 		}
 
 		// Parse the master m3u8 to extract qualities
-		qualities, err := g.parseMasterM3U8(s.File)
+		qualities, err := g.parseMasterM3U8(ctx, s.File)
 		if err != nil {
 			return nil, fmt.Errorf("Gogocdn Extract: failed to parse master m3u8: %w", err)
 		}
@@ -209,15 +233,16 @@ func (g *Gogocdn) Extract(link string) (*types.Source, error) {
 		}
 	}
 
+	cache.SetJSON(ctx, g.cache, cacheKey, sources, cache.SourceTTL(signedURLs...))
 	return sources, nil
 }
 
 // Fetches and parses the web page content, decrypting the necessary
 // data and returning the parsed parameters needed for further extraction.
-func (g *Gogocdn) parsePage(link string, contentID string) (string, error) {
-	response, err := g.baseCrawler.Client.Get(link, nil)
+func (g *Gogocdn) parsePage(ctx context.Context, link string, contentID string) (string, error) {
+	response, err := g.baseCrawler.Client.Get(ctx, link, nil)
 	if err != nil {
-		return "", fmt.Errorf("Gogocdn parsePage: %w", ErrRequest)
+		return "", fmt.Errorf("Gogocdn parsePage: %w : %w", ErrRequest, err)
 	}
 
 	match := g.reEncryptedData.FindSubmatch(response)
@@ -327,14 +352,13 @@ func ensureBaseCrawler(c *crawler.BaseCrawler) *crawler.BaseCrawler {
 }
 
 // calculateIntroOutro extracts the intro and outro segments from the m3u8 playlist
-func (g *Gogocdn) calculateIntroOutro(m3u8URL string) (types.EpisodeTiming, types.EpisodeTiming, error) {
-	resp, err := http.Get(m3u8URL)
+func (g *Gogocdn) calculateIntroOutro(ctx context.Context, m3u8URL string) (types.EpisodeTiming, types.EpisodeTiming, error) {
+	body, err := g.baseCrawler.Client.Get(ctx, m3u8URL, nil)
 	if err != nil {
 		return types.EpisodeTiming{}, types.EpisodeTiming{}, fmt.Errorf("failed to fetch m3u8: %w", err)
 	}
-	defer resp.Body.Close()
 
-	scanner := bufio.NewScanner(resp.Body)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
 	var totalDuration float64
 	var introDuration float64 = 90.0 // Example: Assume intro is always 90 seconds
 	var outroStart float64
@@ -407,89 +431,11 @@ func (g *Gogocdn) calculateIntroOutro(m3u8URL string) (types.EpisodeTiming, type
 // }
 
 // parseMasterM3U8 fetches and parses the master .m3u8 playlist.
-func (g *Gogocdn) parseMasterM3U8(masterURL string) ([]types.Quality, error) {
+func (g *Gogocdn) parseMasterM3U8(ctx context.Context, masterURL string) ([]types.Quality, error) {
 	// Fetch the master .m3u8 content
-	resp, err := http.Get(masterURL)
+	body, err := g.baseCrawler.Client.Get(ctx, masterURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch master m3u8: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 response: %d", resp.StatusCode)
-	}
-
-	scanner := bufio.NewScanner(resp.Body)
-	var qualities []types.Quality
-	var currentQuality types.Quality
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		if strings.HasPrefix(line, "#EXT-X-STREAM-INF") {
-			// Extract attributes
-			attributes := parseAttributes(line)
-			bandwidth, err := strconv.Atoi(attributes["BANDWIDTH"])
-			if err != nil {
-				return nil, fmt.Errorf("invalid BANDWIDTH value: %w", err)
-			}
-
-			currentQuality = types.Quality{
-				Name:       attributes["NAME"],
-				Bandwidth:  bandwidth,
-				Resolution: attributes["RESOLUTION"],
-			}
-		} else if strings.HasSuffix(line, ".m3u8") && currentQuality.Name != "" {
-			// Assign the URL to the current quality
-			currentQuality.SubURL = resolveURL(masterURL, line)
-			qualities = append(qualities, currentQuality)
-			// Reset currentQuality for next entry
-			currentQuality = types.Quality{}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading master m3u8: %w", err)
-	}
-
-	if len(qualities) == 0 {
-		return nil, errors.New("no qualities found in master m3u8")
-	}
-
-	return qualities, nil
-}
-
-// parseAttributes parses the attributes from a #EXT-X-STREAM-INF line.
-func parseAttributes(line string) map[string]string {
-	attributes := make(map[string]string)
-	// Remove the prefix
-	parts := strings.SplitN(line, ":", 2)
-	if len(parts) != 2 {
-		return attributes
-	}
-
-	attrs := strings.Split(parts[1], ",")
-	for _, attr := range attrs {
-		kv := strings.SplitN(attr, "=", 2)
-		if len(kv) == 2 {
-			key := strings.TrimSpace(kv[0])
-			value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
-			attributes[key] = value
-		}
-	}
-	return attributes
-}
-
-// resolveURL resolves relative URLs based on the master playlist URL.
-func resolveURL(masterURL, relativeURL string) string {
-	u, err := url.Parse(masterURL)
-	if err != nil {
-		return relativeURL // Fallback to the relative URL if parsing fails
-	}
-	base := u.Scheme + "://" + u.Host + path.Dir(u.Path)
-	resolvedURL, err := url.Parse(relativeURL)
-	if err != nil {
-		return relativeURL
-	}
-	return base + "/" + resolvedURL.Path
+	return parseMasterPlaylist(masterURL, body)
 }
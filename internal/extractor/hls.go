@@ -0,0 +1,95 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"aniverse/internal/types"
+)
+
+// parseMasterPlaylist reads the variant streams of the master .m3u8 playlist
+// fetched from masterURL as qualities. Variants without a NAME are named after
+// the height of their RESOLUTION, e.g. "720p".
+func parseMasterPlaylist(masterURL string, body []byte) ([]types.Quality, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	var qualities []types.Quality
+	var currentQuality types.Quality
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "#EXT-X-STREAM-INF") {
+			// Extract attributes
+			attributes := parseAttributes(line)
+			bandwidth, err := strconv.Atoi(attributes["BANDWIDTH"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid BANDWIDTH value: %w", err)
+			}
+
+			currentQuality = types.Quality{
+				Name:       attributes["NAME"],
+				Bandwidth:  bandwidth,
+				Resolution: attributes["RESOLUTION"],
+			}
+			if _, height, ok := strings.Cut(currentQuality.Resolution, "x"); ok && currentQuality.Name == "" {
+				currentQuality.Name = height + "p"
+			}
+		} else if line != "" && !strings.HasPrefix(line, "#") && currentQuality.Name != "" {
+			// The URI line following #EXT-X-STREAM-INF is the variant playlist
+			currentQuality.SubURL = resolveURL(masterURL, line)
+			qualities = append(qualities, currentQuality)
+			// Reset currentQuality for next entry
+			currentQuality = types.Quality{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading master m3u8: %w", err)
+	}
+
+	if len(qualities) == 0 {
+		return nil, errors.New("no qualities found in master m3u8")
+	}
+
+	return qualities, nil
+}
+
+// parseAttributes parses the attributes from a #EXT-X-STREAM-INF line.
+func parseAttributes(line string) map[string]string {
+	attributes := make(map[string]string)
+	// Remove the prefix
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return attributes
+	}
+
+	attrs := strings.Split(parts[1], ",")
+	for _, attr := range attrs {
+		kv := strings.SplitN(attr, "=", 2)
+		if len(kv) == 2 {
+			key := strings.TrimSpace(kv[0])
+			value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			attributes[key] = value
+		}
+	}
+	return attributes
+}
+
+// resolveURL resolves the URI of a variant playlist against the master
+// playlist URL. Absolute URIs and query strings are kept.
+func resolveURL(masterURL, relativeURL string) string {
+	base, err := url.Parse(masterURL)
+	if err != nil {
+		return relativeURL // Fallback to the relative URL if parsing fails
+	}
+	resolvedURL, err := url.Parse(relativeURL)
+	if err != nil {
+		return relativeURL
+	}
+	return base.ResolveReference(resolvedURL).String()
+}
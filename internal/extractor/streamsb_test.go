@@ -0,0 +1,207 @@
+package extractor
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
+	"aniverse/internal/types"
+)
+
+// streamSBSources maps the video IDs the fake host knows to their recorded
+// sources API response; any other ID is a 404.
+var streamSBSources = map[string]string{
+	"abc123":     "sources.json",
+	"nostream":   "sources_null.json",
+	"nofile":     "sources_empty_file.json",
+	"novariants": "sources_no_variants.json",
+}
+
+// streamSBHost serves the recorded fixtures under testdata/streamsb like a
+// StreamSB embed host, and records the headers each path was fetched with.
+type streamSBHost struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	headers map[string]http.Header
+}
+
+func newStreamSBHost(t *testing.T) *streamSBHost {
+	t.Helper()
+	host := &streamSBHost{headers: map[string]http.Header{}}
+	host.Server = httptest.NewServer(http.HandlerFunc(host.serve))
+	t.Cleanup(host.Close)
+	return host
+}
+
+func (h *streamSBHost) serve(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.headers[r.URL.Path] = r.Header.Clone()
+	h.mu.Unlock()
+
+	var fixture string
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/e/"):
+		fixture = "embed.html"
+	case strings.HasPrefix(r.URL.Path, "/sources50/"):
+		payload, err := hex.DecodeString(strings.TrimPrefix(r.URL.Path, "/sources50/"))
+		parts := strings.Split(string(payload), "||")
+		if err != nil || len(parts) != 4 || parts[0] != streamSBPadding || parts[3] != "streamsb" {
+			http.Error(w, "bad payload", http.StatusBadRequest)
+			return
+		}
+		fixture = streamSBSources[parts[1]]
+	case r.URL.Path == "/hls/abc123/master.m3u8":
+		fixture = "master.m3u8"
+	case r.URL.Path == "/hls/novariants/master.m3u8":
+		fixture = "master_no_variants.m3u8"
+	}
+	if fixture == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := os.ReadFile(filepath.Join("testdata", "streamsb", fixture))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(strings.ReplaceAll(string(body), "{{origin}}", h.URL)))
+}
+
+// requestHeaders returns the headers path was last fetched with.
+func (h *streamSBHost) requestHeaders(path string) http.Header {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.headers[path]
+}
+
+func newTestStreamSB() *StreamSB {
+	return NewStreamSB(config.StreamSBConfig{Hosts: []string{"127.0.0.1"}, SourcesPath: "/sources50/"}, nil, cache.New("memory", "", 100))
+}
+
+func TestStreamSBExtract(t *testing.T) {
+	host := newStreamSBHost(t)
+	link := host.URL + "/e/abc123.html"
+
+	s := newTestStreamSB()
+	if !s.Matches(link) {
+		t.Fatalf("Matches(%q) = false", link)
+	}
+	source, err := s.Extract(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	wantSources := []types.Quality{
+		{Name: "720p", Bandwidth: 1396000, Resolution: "1280x720", SubURL: host.URL + "/hls/abc123/index-v1-a1.m3u8?t=9f2c"},
+		{Name: "360p", Bandwidth: 545000, Resolution: "640x360", SubURL: host.URL + "/hls/abc123/index-v2-a1.m3u8?t=9f2c"},
+		{Name: "Full HD", Bandwidth: 2780000, Resolution: "1920x1080", SubURL: "https://cdn.streamsb.test/hls/abc123/index-v0-a1.m3u8?t=9f2c"},
+	}
+	if !reflect.DeepEqual(source.Sources, wantSources) {
+		t.Errorf("Sources = %+v, want %+v", source.Sources, wantSources)
+	}
+
+	wantSubtitles := []string{host.URL + "/srt/abc123_eng.vtt", host.URL + "/srt/abc123_spa.vtt"}
+	if !reflect.DeepEqual(source.Subtitles, wantSubtitles) {
+		t.Errorf("Subtitles = %v, want %v", source.Subtitles, wantSubtitles)
+	}
+
+	wantHeaders := map[string]string{"Referer": link, "Origin": host.URL}
+	if !reflect.DeepEqual(source.Headers, wantHeaders) {
+		t.Errorf("Headers = %v, want %v", source.Headers, wantHeaders)
+	}
+	if !source.IsM3U8 {
+		t.Error("IsM3U8 = false, want true")
+	}
+
+	// The sources API and the CDN only answer the embed page.
+	payload := hex.EncodeToString([]byte(streamSBPadding + "||abc123||" + streamSBPadding + "||streamsb"))
+	api := host.requestHeaders("/sources50/" + payload)
+	if api == nil {
+		t.Fatal("sources API was not called")
+	}
+	if api.Get("Referer") != link || api.Get("watchsb") != "sbstream" {
+		t.Errorf("sources API headers = %v, want Referer %s and watchsb sbstream", api, link)
+	}
+	master := host.requestHeaders("/hls/abc123/master.m3u8")
+	if master.Get("Referer") != link || master.Get("Origin") != host.URL {
+		t.Errorf("master playlist headers = %v, want Referer %s and Origin %s", master, link, host.URL)
+	}
+}
+
+func TestStreamSBExtractCaches(t *testing.T) {
+	host := newStreamSBHost(t)
+	link := host.URL + "/e/abc123.html"
+
+	s := newTestStreamSB()
+	first, err := s.Extract(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	host.Close()
+
+	second, err := s.Extract(context.Background(), link)
+	if err != nil {
+		t.Fatalf("Extract() from cache error = %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached source = %+v, want %+v", second, first)
+	}
+}
+
+func TestStreamSBExtractErrors(t *testing.T) {
+	host := newStreamSBHost(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr error
+	}{
+		{"null stream_data", "/e/nostream.html", ErrNoContent},
+		{"empty file", "/e/nofile.html", ErrNoContent},
+		{"no video ID", "/e/", ErrInvalidArgument},
+		{"unknown video ID", "/e/missing.html", ErrRequest},
+		{"master without variants", "/e/novariants.html", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := newTestStreamSB().Extract(context.Background(), host.URL+tt.path)
+			if err == nil {
+				t.Fatalf("Extract() = %+v, want an error", source)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Extract() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !strings.Contains(err.Error(), "no qualities found") {
+				t.Errorf("Extract() error = %v, want no qualities found", err)
+			}
+		})
+	}
+}
+
+// The extractor builds the sources API request itself rather than loading
+// the embed page; the recorded page is what its padding and path follow.
+func TestStreamSBEmbedPage(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "streamsb", "embed.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `sbPadding = "` + streamSBPadding + `"`; !strings.Contains(string(page), want) {
+		t.Errorf("embed page does not use padding %s", streamSBPadding)
+	}
+	if want := `url: "/` + config.Default().StreamSB.SourcesPath + `/"`; !strings.Contains(string(page), want) {
+		t.Errorf("embed page does not call the default sources path %s", config.Default().StreamSB.SourcesPath)
+	}
+}
@@ -0,0 +1,118 @@
+// Package graph serves the Aniverse data over GraphQL, so clients can ask for
+// exactly the fields they need in one round trip.
+package graph
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/httpclient"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+// maxDepth bounds how deeply queries may nest, e.g. relations of relations.
+const maxDepth = 8
+
+// maxParallelism is how many resolvers of a query may run at once. Resolvers
+// waiting on the media loader hold their slot, so it has to cover a page of
+// list items for their lookups to land in one batch.
+const maxParallelism = 64
+
+// Backend fetches what the metadata provider alone does not know: episodes
+// come from the mapped episode provider and sources from its extractor.
+type Backend interface {
+	Episodes(ctx context.Context, anime *types.AnimeInfo) ([]types.Episode, error)
+	Source(ctx context.Context, animeID string, episode int, dub bool) (*types.Source, error)
+}
+
+// Schema executes GraphQL queries against the providers of a registry.
+type Schema struct {
+	schema   *graphql.Schema
+	registry *provider.Registry
+	backend  Backend
+}
+
+// NewSchema creates a Schema. It panics if the schema does not match the
+// resolvers, which is a programming error.
+func NewSchema(registry *provider.Registry, backend Backend) *Schema {
+	return &Schema{
+		schema:   graphql.MustParseSchema(schema, &resolver{}, graphql.MaxDepth(maxDepth), graphql.MaxParallelism(maxParallelism)),
+		registry: registry,
+		backend:  backend,
+	}
+}
+
+// Exec runs a query. Every query gets its own loaders, so nothing it fetched
+// is shared with other queries beyond the provider caches.
+func (s *Schema) Exec(ctx context.Context, query string, operationName string, variables map[string]interface{}) *graphql.Response {
+	ctx = withLoaders(ctx, newLoaders(ctx, s.backend, s.registry.BatchMeta()))
+	return s.schema.Exec(ctx, query, operationName, variables)
+}
+
+// queryError is a resolver error as GraphQL clients see it: a safe message,
+// with the code and provider of the REST error envelope as extensions.
+type queryError struct {
+	code     apperror.Kind
+	provider string
+	message  string
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+func (e *queryError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	if e.provider != "" {
+		extensions["provider"] = e.provider
+	}
+	return extensions
+}
+
+// toQueryError classifies err like the REST error handler does.
+func toQueryError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		rateLimited *httpclient.RateLimitError
+		appErr      *apperror.Error
+		qErr        *queryError
+	)
+	if errors.As(err, &qErr) {
+		return qErr
+	}
+
+	qErr = &queryError{}
+	if errors.As(err, &appErr) {
+		qErr.provider = appErr.Provider
+	}
+
+	switch {
+	case errors.As(err, &rateLimited):
+		qErr.code = apperror.KindRateLimited
+		qErr.message = "Upstream " + rateLimited.Host + " is rate limiting us, retry later."
+	case errors.Is(err, context.DeadlineExceeded):
+		qErr.code = apperror.KindTimeout
+		qErr.message = "The request took too long to complete."
+	case appErr != nil:
+		qErr.code = appErr.Kind
+		qErr.message = appErr.Message
+		if qErr.message == "" {
+			qErr.message = string(appErr.Kind)
+		}
+	default:
+		log.Printf("GraphQL resolver failed: %v", err)
+		qErr.code = apperror.KindInternal
+		qErr.message = "Internal server error."
+	}
+	return qErr
+}
+
+var errNoMetaProvider = apperror.New(apperror.KindUpstreamUnavailable, "", "No metadata provider available.")
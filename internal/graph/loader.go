@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+// batchWait is how long the media loader collects IDs before fetching them.
+// Resolvers of list items run concurrently, so their lookups arrive within it.
+const batchWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders caches what the resolvers of one query fetch, so a nested field
+// asked for on many items costs one upstream request per distinct key, and
+// anime lookups are batched.
+type loaders struct {
+	// ctx is the query's context. Batches run under it rather than under
+	// the context of whichever resolver happened to ask first.
+	ctx     context.Context
+	backend Backend
+	meta    provider.BatchMetaProvider
+
+	mu       sync.Mutex
+	media    map[string]*call
+	pending  []string
+	episodes map[string]*call
+	sources  map[string]*call
+}
+
+// call is a fetch in flight or done; done is closed once value and err are set.
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newLoaders(ctx context.Context, backend Backend, meta provider.BatchMetaProvider) *loaders {
+	return &loaders{
+		ctx:      ctx,
+		backend:  backend,
+		meta:     meta,
+		media:    make(map[string]*call),
+		episodes: make(map[string]*call),
+		sources:  make(map[string]*call),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// Media returns the anime with the AniList ID. IDs asked for within batchWait
+// of each other are fetched together with GetMediaBatch.
+func (l *loaders) Media(ctx context.Context, id string) (*types.AnimeInfo, error) {
+	if l.meta == nil {
+		return nil, errNoMetaProvider
+	}
+
+	l.mu.Lock()
+	c, ok := l.media[id]
+	if !ok {
+		c = &call{done: make(chan struct{})}
+		l.media[id] = c
+		l.pending = append(l.pending, id)
+		if len(l.pending) == 1 {
+			time.AfterFunc(batchWait, l.fetchMedia)
+		}
+	}
+	l.mu.Unlock()
+
+	value, err := c.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	media, _ := value.(*types.AnimeInfo)
+	return media, nil
+}
+
+// fetchMedia fetches the pending IDs and completes their calls.
+func (l *loaders) fetchMedia() {
+	l.mu.Lock()
+	ids := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+
+	results := l.meta.GetMediaBatch(l.ctx, ids)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, result := range results {
+		c := l.media[result.ID]
+		c.value, c.err = result.Media, apperror.Upstream(l.meta.ID(), result.Err)
+		close(c.done)
+	}
+}
+
+// Episodes returns the episodes of anime.
+func (l *loaders) Episodes(ctx context.Context, anime *types.AnimeInfo) ([]types.Episode, error) {
+	c, first := l.once(l.episodes, anime.ID)
+	if first {
+		c.value, c.err = l.backend.Episodes(ctx, anime)
+		close(c.done)
+	}
+
+	value, err := c.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	episodes, _ := value.([]types.Episode)
+	return episodes, nil
+}
+
+// Source returns the stream of episode of the anime with the AniList ID.
+func (l *loaders) Source(ctx context.Context, animeID string, episode int, dub bool) (*types.Source, error) {
+	c, first := l.once(l.sources, fmt.Sprintf("%s:%d:%t", animeID, episode, dub))
+	if first {
+		c.value, c.err = l.backend.Source(ctx, animeID, episode, dub)
+		close(c.done)
+	}
+
+	value, err := c.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	source, _ := value.(*types.Source)
+	return source, nil
+}
+
+// once returns the call for key in calls, creating it if needed; first
+// reports whether the caller created it and so has to complete it.
+func (l *loaders) once(calls map[string]*call, key string) (c *call, first bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c, ok := calls[key]; ok {
+		return c, false
+	}
+	c = &call{done: make(chan struct{})}
+	calls[key] = c
+	return c, true
+}
+
+// wait blocks until c is done or ctx is cancelled.
+func (c *call) wait(ctx context.Context) (interface{}, error) {
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
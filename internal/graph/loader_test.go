@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+type requestKey struct{}
+
+// fakeMeta serves anime whose relations are given by children, recording
+// every GetMediaBatch call.
+type fakeMeta struct {
+	children map[string][]string
+
+	mu      sync.Mutex
+	batches [][]string
+	values  []interface{}
+}
+
+func (f *fakeMeta) ID() string               { return "fake" }
+func (f *fakeMeta) URL() string              { return "https://fake.test" }
+func (f *fakeMeta) Formats() []types.Format  { return nil }
+func (f *fakeMeta) NeedsProxy() bool         { return false }
+func (f *fakeMeta) UseGoogleTranslate() bool { return false }
+func (f *fakeMeta) Search(context.Context, types.MediaFilter) (*types.AnimePage, error) {
+	return &types.AnimePage{}, nil
+}
+
+func (f *fakeMeta) GetMedia(ctx context.Context, id string) (*types.AnimeInfo, error) {
+	anime := &types.AnimeInfo{ID: id}
+	for _, child := range f.children[id] {
+		anime.Relations = append(anime.Relations, types.Relation{ID: child, Type: types.TypeAnime})
+	}
+	return anime, nil
+}
+
+func (f *fakeMeta) GetMediaBatch(ctx context.Context, ids []string) []types.MediaResult {
+	f.mu.Lock()
+	batch := append([]string(nil), ids...)
+	sort.Strings(batch)
+	f.batches = append(f.batches, batch)
+	f.values = append(f.values, ctx.Value(requestKey{}))
+	f.mu.Unlock()
+
+	results := make([]types.MediaResult, len(ids))
+	for i, id := range ids {
+		media, err := f.GetMedia(ctx, id)
+		results[i] = types.MediaResult{ID: id, Media: media, Err: err}
+	}
+	return results
+}
+
+func TestRelationsAreBatched(t *testing.T) {
+	// Anime 1 has 8 relations, each with 2 of its own: more siblings than
+	// graphql-go runs in parallel by default.
+	meta := &fakeMeta{children: map[string][]string{}}
+	var second, third []string
+	for i := 0; i < 8; i++ {
+		child := fmt.Sprint(10 + i)
+		second = append(second, child)
+		for j := 0; j < 2; j++ {
+			grandchild := fmt.Sprint(100 + 2*i + j)
+			meta.children[child] = append(meta.children[child], grandchild)
+			third = append(third, grandchild)
+		}
+	}
+	meta.children["1"] = second
+	sort.Strings(second)
+	sort.Strings(third)
+
+	registry := provider.NewRegistry()
+	registry.Register(meta)
+	schema := NewSchema(registry, nil)
+
+	ctx := context.WithValue(context.Background(), requestKey{}, "request")
+	response := schema.Exec(ctx, `{ info(id: "1") { relations { anime { id relations { anime { id } } } } } }`, "", nil)
+	if len(response.Errors) > 0 {
+		t.Fatalf("Exec() errors = %v", response.Errors)
+	}
+	if got := strings.Count(string(response.Data), `"id"`); got != len(second)+len(third) {
+		t.Errorf("response has %d related anime, want %d: %s", got, len(second)+len(third), response.Data)
+	}
+
+	want := [][]string{{"1"}, second, third}
+	if len(meta.batches) != len(want) {
+		t.Fatalf("GetMediaBatch called %d times, want once per level: %v", len(meta.batches), meta.batches)
+	}
+	for i := range want {
+		if strings.Join(meta.batches[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("batch %d = %v, want %v", i, meta.batches[i], want[i])
+		}
+		if meta.values[i] != "request" {
+			t.Errorf("batch %d ran outside the request context", i)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/types"
+)
+
+// maxPerPage bounds the perPage argument of search, as AniList does.
+const maxPerPage = 50
+
+// resolver resolves the Query type. It is stateless; everything a query
+// fetches goes through the loaders in its context.
+type resolver struct{}
+
+func (r *resolver) Search(ctx context.Context, args struct {
+	Query   string
+	Page    int32
+	PerPage int32
+}) (*animePageResolver, error) {
+	if args.Query == "" {
+		return nil, toQueryError(apperror.BadRequest("Missing 'query' argument."))
+	}
+	if args.Page < 1 || args.PerPage < 1 || args.PerPage > maxPerPage {
+		return nil, toQueryError(apperror.BadRequest("'page' must be positive and 'perPage' between 1 and 50."))
+	}
+
+	meta := loadersFrom(ctx).meta
+	if meta == nil {
+		return nil, toQueryError(errNoMetaProvider)
+	}
+
+	page, err := meta.Search(ctx, types.MediaFilter{
+		Search:  args.Query,
+		Page:    int(args.Page),
+		PerPage: int(args.PerPage),
+	})
+	if err != nil {
+		return nil, toQueryError(apperror.Upstream(meta.ID(), err))
+	}
+	return &animePageResolver{page: page}, nil
+}
+
+func (r *resolver) Info(ctx context.Context, args struct{ ID graphql.ID }) (*animeResolver, error) {
+	media, err := loadersFrom(ctx).Media(ctx, string(args.ID))
+	if err != nil {
+		return nil, toQueryError(err)
+	}
+	return &animeResolver{anime: media}, nil
+}
+
+func (r *resolver) Episodes(ctx context.Context, args struct{ ID graphql.ID }) ([]*episodeResolver, error) {
+	anime, err := r.Info(ctx, struct{ ID graphql.ID }{args.ID})
+	if err != nil {
+		return nil, err
+	}
+	return anime.Episodes(ctx)
+}
+
+func (r *resolver) Sources(ctx context.Context, args struct {
+	ID      graphql.ID
+	Episode int32
+	Dub     bool
+}) (*sourceResolver, error) {
+	if args.Episode < 1 {
+		return nil, toQueryError(apperror.BadRequest("'episode' must be a positive integer."))
+	}
+
+	source, err := loadersFrom(ctx).Source(ctx, string(args.ID), int(args.Episode), args.Dub)
+	if err != nil {
+		return nil, toQueryError(err)
+	}
+	return &sourceResolver{source: source}, nil
+}
+
+type animePageResolver struct {
+	page *types.AnimePage
+}
+
+func (r *animePageResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{info: r.page.PageInfo}
+}
+
+func (r *animePageResolver) Results() []*animeResolver {
+	results := make([]*animeResolver, len(r.page.Results))
+	for i := range r.page.Results {
+		results[i] = &animeResolver{anime: &r.page.Results[i]}
+	}
+	return results
+}
+
+type pageInfoResolver struct {
+	info types.PageInfo
+}
+
+func (r *pageInfoResolver) Total() int32       { return int32(r.info.Total) }
+func (r *pageInfoResolver) PerPage() int32     { return int32(r.info.PerPage) }
+func (r *pageInfoResolver) CurrentPage() int32 { return int32(r.info.CurrentPage) }
+func (r *pageInfoResolver) LastPage() int32    { return int32(r.info.LastPage) }
+func (r *pageInfoResolver) HasNextPage() bool  { return r.info.HasNextPage }
+
+type titleResolver struct {
+	title types.Title
+}
+
+func (r *titleResolver) English() string { return r.title.English }
+func (r *titleResolver) Romaji() string  { return r.title.Romaji }
+func (r *titleResolver) Native() string  { return r.title.Native }
+
+type animeResolver struct {
+	anime *types.AnimeInfo
+}
+
+func (r *animeResolver) ID() graphql.ID { return graphql.ID(r.anime.ID) }
+
+func (r *animeResolver) IDMal() *graphql.ID {
+	if r.anime.IDMal == "" {
+		return nil
+	}
+	id := graphql.ID(r.anime.IDMal)
+	return &id
+}
+
+func (r *animeResolver) Title() *titleResolver    { return &titleResolver{title: r.anime.Title} }
+func (r *animeResolver) Synonyms() []string       { return nonNil(r.anime.Synonyms) }
+func (r *animeResolver) Description() *string     { return r.anime.Description }
+func (r *animeResolver) BannerImage() *string     { return r.anime.BannerImage }
+func (r *animeResolver) Trailer() *string         { return r.anime.Trailer }
+func (r *animeResolver) Color() *string           { return r.anime.Color }
+func (r *animeResolver) Format() string           { return string(r.anime.Format) }
+func (r *animeResolver) Status() string           { return string(r.anime.Status) }
+func (r *animeResolver) CountryOfOrigin() *string { return r.anime.CountryOfOrigin }
+func (r *animeResolver) Genres() []string         { return nonNil(r.anime.Genres) }
+func (r *animeResolver) Tags() []string           { return nonNil(r.anime.Tags) }
+func (r *animeResolver) Rating() *float64         { return r.anime.Rating }
+func (r *animeResolver) Popularity() int32        { return int32(r.anime.Popularity) }
+func (r *animeResolver) Year() *int32             { return optionalInt(r.anime.Year) }
+func (r *animeResolver) Duration() *int32         { return optionalInt(r.anime.Duration) }
+func (r *animeResolver) TotalEpisodes() int32     { return int32(r.anime.TotalEpisodes) }
+func (r *animeResolver) CurrentEpisode() int32    { return int32(r.anime.CurrentEpisode) }
+
+func (r *animeResolver) CoverImage() *string {
+	if r.anime.CoverImage == nil {
+		return nil
+	}
+	return &r.anime.CoverImage.Large
+}
+
+func (r *animeResolver) Season() *string {
+	if r.anime.Season == nil {
+		return nil
+	}
+	season := string(*r.anime.Season)
+	return &season
+}
+
+func (r *animeResolver) Relations() []*relationResolver {
+	relations := make([]*relationResolver, len(r.anime.Relations))
+	for i, relation := range r.anime.Relations {
+		relations[i] = &relationResolver{relation: relation}
+	}
+	return relations
+}
+
+func (r *animeResolver) Characters() []*characterResolver {
+	characters := make([]*characterResolver, len(r.anime.Characters))
+	for i, character := range r.anime.Characters {
+		characters[i] = &characterResolver{character: character}
+	}
+	return characters
+}
+
+func (r *animeResolver) Episodes(ctx context.Context) ([]*episodeResolver, error) {
+	episodes, err := loadersFrom(ctx).Episodes(ctx, r.anime)
+	if err != nil {
+		return nil, toQueryError(err)
+	}
+
+	resolvers := make([]*episodeResolver, len(episodes))
+	for i, episode := range episodes {
+		resolvers[i] = &episodeResolver{animeID: r.anime.ID, episode: episode}
+	}
+	return resolvers, nil
+}
+
+type relationResolver struct {
+	relation types.Relation
+}
+
+func (r *relationResolver) ID() graphql.ID        { return graphql.ID(r.relation.ID) }
+func (r *relationResolver) RelationType() string  { return r.relation.RelationType }
+func (r *relationResolver) Format() string        { return string(r.relation.Format) }
+func (r *relationResolver) Type() string          { return string(r.relation.Type) }
+func (r *relationResolver) Title() *titleResolver { return &titleResolver{title: r.relation.Title} }
+
+// Anime looks the related anime up through the media loader, so the
+// relations of every anime in a query are fetched in one batch.
+func (r *relationResolver) Anime(ctx context.Context) (*animeResolver, error) {
+	if r.relation.Type != types.TypeAnime {
+		return nil, nil
+	}
+
+	media, err := loadersFrom(ctx).Media(ctx, r.relation.ID)
+	if err != nil {
+		return nil, toQueryError(err)
+	}
+	return &animeResolver{anime: media}, nil
+}
+
+type characterResolver struct {
+	character types.Character
+}
+
+func (r *characterResolver) ID() graphql.ID { return graphql.ID(r.character.ID) }
+func (r *characterResolver) Name() string   { return r.character.Name }
+func (r *characterResolver) Image() string  { return r.character.Image }
+
+func (r *characterResolver) Role() *string {
+	if r.character.Role == "" {
+		return nil
+	}
+	return &r.character.Role
+}
+
+func (r *characterResolver) VoiceActors() []*voiceActorResolver {
+	voiceActors := make([]*voiceActorResolver, len(r.character.VoiceActors))
+	for i, voiceActor := range r.character.VoiceActors {
+		voiceActors[i] = &voiceActorResolver{voiceActor: voiceActor}
+	}
+	return voiceActors
+}
+
+type voiceActorResolver struct {
+	voiceActor types.VoiceActor
+}
+
+func (r *voiceActorResolver) ID() graphql.ID   { return graphql.ID(r.voiceActor.ID) }
+func (r *voiceActorResolver) Name() string     { return r.voiceActor.Name }
+func (r *voiceActorResolver) Image() string    { return r.voiceActor.Image }
+func (r *voiceActorResolver) Language() string { return r.voiceActor.Language }
+
+type episodeResolver struct {
+	animeID string
+	episode types.Episode
+}
+
+func (r *episodeResolver) ID() graphql.ID       { return graphql.ID(r.episode.ID) }
+func (r *episodeResolver) Number() int32        { return int32(r.episode.Number) }
+func (r *episodeResolver) Description() *string { return r.episode.Description }
+func (r *episodeResolver) Img() *string         { return r.episode.Img }
+func (r *episodeResolver) IsFiller() bool       { return r.episode.IsFiller }
+func (r *episodeResolver) HasSub() bool         { return r.episode.HasSub }
+func (r *episodeResolver) HasDub() bool         { return r.episode.HasDub }
+func (r *episodeResolver) Rating() *float64     { return r.episode.Rating }
+
+func (r *episodeResolver) Title() *string {
+	if r.episode.EpisodeTitle == "" {
+		return nil
+	}
+	return &r.episode.EpisodeTitle
+}
+
+func (r *episodeResolver) Source(ctx context.Context, args struct{ Dub bool }) (*sourceResolver, error) {
+	source, err := loadersFrom(ctx).Source(ctx, r.animeID, r.episode.Number, args.Dub)
+	if err != nil {
+		return nil, toQueryError(err)
+	}
+	return &sourceResolver{source: source}, nil
+}
+
+type sourceResolver struct {
+	source *types.Source
+}
+
+func (r *sourceResolver) Subtitles() []string { return nonNil(r.source.Subtitles) }
+func (r *sourceResolver) Audio() []string     { return nonNil(r.source.Audio) }
+func (r *sourceResolver) IsM3U8() bool        { return r.source.IsM3U8 }
+func (r *sourceResolver) Thumbnail() string   { return r.source.Thumbnail }
+
+func (r *sourceResolver) Intro() *timingResolver { return &timingResolver{timing: r.source.Intro} }
+func (r *sourceResolver) Outro() *timingResolver { return &timingResolver{timing: r.source.Outro} }
+
+func (r *sourceResolver) Qualities() []*qualityResolver {
+	qualities := make([]*qualityResolver, len(r.source.Sources))
+	for i, quality := range r.source.Sources {
+		qualities[i] = &qualityResolver{quality: quality}
+	}
+	return qualities
+}
+
+// Headers lists the headers sorted by name, as GraphQL has no map type.
+func (r *sourceResolver) Headers() []*headerResolver {
+	headers := make([]*headerResolver, 0, len(r.source.Headers))
+	for name, value := range r.source.Headers {
+		headers = append(headers, &headerResolver{name: name, value: value})
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].name < headers[j].name })
+	return headers
+}
+
+type qualityResolver struct {
+	quality types.Quality
+}
+
+func (r *qualityResolver) Quality() string    { return r.quality.Name }
+func (r *qualityResolver) Bandwidth() int32   { return int32(r.quality.Bandwidth) }
+func (r *qualityResolver) Resolution() string { return r.quality.Resolution }
+func (r *qualityResolver) Sub() *string       { return optionalString(r.quality.SubURL) }
+func (r *qualityResolver) Dub() *string       { return optionalString(r.quality.DubURL) }
+
+type timingResolver struct {
+	timing types.EpisodeTiming
+}
+
+func (r *timingResolver) Start() float64 { return r.timing.Start }
+func (r *timingResolver) End() float64   { return r.timing.End }
+
+type headerResolver struct {
+	name  string
+	value string
+}
+
+func (r *headerResolver) Name() string  { return r.name }
+func (r *headerResolver) Value() string { return r.value }
+
+// nonNil turns a nil slice into an empty one for non-null list fields.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func optionalInt(value *int) *int32 {
+	if value == nil {
+		return nil
+	}
+	converted := int32(*value)
+	return &converted
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,134 @@
+package graph
+
+// schema is the GraphQL schema served at /graphql. Types mirror the JSON of
+// the REST endpoints; enums are strings so unknown upstream values never fail
+// a query.
+const schema = `
+schema {
+  query: Query
+}
+
+type Query {
+  # Anime matching the title, most relevant first.
+  search(query: String!, page: Int = 1, perPage: Int = 20): AnimePage!
+  # The anime with the AniList ID.
+  info(id: ID!): AnimeInfo
+  # The episodes of the anime with the AniList ID.
+  episodes(id: ID!): [Episode!]!
+  # The stream of an episode of the anime with the AniList ID.
+  sources(id: ID!, episode: Int!, dub: Boolean = false): Source
+}
+
+type AnimePage {
+  pageInfo: PageInfo!
+  results: [AnimeInfo!]!
+}
+
+type PageInfo {
+  total: Int!
+  perPage: Int!
+  currentPage: Int!
+  lastPage: Int!
+  hasNextPage: Boolean!
+}
+
+type Title {
+  english: String!
+  romaji: String!
+  native: String!
+}
+
+type AnimeInfo {
+  id: ID!
+  idMal: ID
+  title: Title!
+  synonyms: [String!]!
+  description: String
+  coverImage: String
+  bannerImage: String
+  trailer: String
+  color: String
+  format: String!
+  status: String!
+  season: String
+  year: Int
+  countryOfOrigin: String
+  genres: [String!]!
+  tags: [String!]!
+  rating: Float
+  popularity: Int!
+  duration: Int
+  totalEpisodes: Int!
+  currentEpisode: Int!
+  relations: [Relation!]!
+  characters: [Character!]!
+  episodes: [Episode!]!
+}
+
+type Relation {
+  id: ID!
+  relationType: String!
+  format: String!
+  type: String!
+  title: Title!
+  # The related anime; null for manga and novels.
+  anime: AnimeInfo
+}
+
+type Character {
+  id: ID!
+  name: String!
+  image: String!
+  role: String
+  voiceActors: [VoiceActor!]!
+}
+
+type VoiceActor {
+  id: ID!
+  name: String!
+  image: String!
+  language: String!
+}
+
+type Episode {
+  id: ID!
+  number: Int!
+  title: String
+  description: String
+  img: String
+  isFiller: Boolean!
+  hasSub: Boolean!
+  hasDub: Boolean!
+  rating: Float
+  source(dub: Boolean = false): Source
+}
+
+type Source {
+  qualities: [Quality!]!
+  subtitles: [String!]!
+  audio: [String!]!
+  isM3U8: Boolean!
+  intro: Timing!
+  outro: Timing!
+  headers: [Header!]!
+  thumbnail: String!
+}
+
+type Quality {
+  quality: String!
+  bandwidth: Int!
+  resolution: String!
+  sub: String
+  dub: String
+}
+
+type Timing {
+  start: Float!
+  end: Float!
+}
+
+type Header {
+  name: String!
+  value: String!
+}
+`
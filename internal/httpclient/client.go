@@ -0,0 +1,412 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Common errors returned by the client.
+var (
+	ErrBodyTooLarge = errors.New("response body exceeds size limit")
+	ErrStatus       = errors.New("unexpected HTTP status")
+)
+
+// StatusError reports an unexpected response status. It matches ErrStatus.
+type StatusError struct {
+	Code int
+	URL  string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %d from %s", ErrStatus, e.Code, e.URL)
+}
+
+func (e *StatusError) Is(target error) bool {
+	return target == ErrStatus
+}
+
+// Config controls timeouts, retries and limits of a Client.
+type Config struct {
+	// Timeout bounds a single attempt, including reading the body. Zero
+	// leaves attempts unbounded.
+	Timeout time.Duration
+	// ResponseHeaderTimeout bounds the wait for the response headers only.
+	ResponseHeaderTimeout time.Duration
+	// HostTimeouts overrides Timeout for specific hosts (e.g. "graphql.anilist.co").
+	HostTimeouts map[string]time.Duration
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// BaseBackoff and MaxBackoff bound the jittered exponential backoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// MaxRetryAfter is the longest Retry-After we are willing to wait for.
+	MaxRetryAfter time.Duration
+	// MaxBodyBytes caps the size of any response body. Zero disables the cap.
+	MaxBodyBytes int64
+	// UserAgent is sent when a request does not set one.
+	UserAgent string
+	// RateLimits holds outbound token buckets keyed by upstream host.
+	RateLimits map[string]Limit
+	// MaxQueueWait is how long a request may queue for a token before
+	// failing fast with a *RateLimitError.
+	MaxQueueWait time.Duration
+}
+
+// DefaultConfig returns the settings used by Default.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:       15 * time.Second,
+		HostTimeouts:  map[string]time.Duration{},
+		MaxRetries:    3,
+		BaseBackoff:   250 * time.Millisecond,
+		MaxBackoff:    5 * time.Second,
+		MaxRetryAfter: 10 * time.Second,
+		MaxBodyBytes:  20 << 20,
+		UserAgent:     "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		RateLimits: map[string]Limit{
+			// AniList allows about 90 requests per minute.
+			"graphql.anilist.co": {Requests: 90, Per: time.Minute, Burst: 10},
+		},
+		MaxQueueWait: 5 * time.Second,
+	}
+}
+
+// StreamingConfig returns the settings of Streaming: a response must start
+// within 15 seconds, but its body may take as long as the caller reads it
+// and be of any size.
+func StreamingConfig() Config {
+	config := DefaultConfig()
+	config.Timeout = 0
+	config.ResponseHeaderTimeout = 15 * time.Second
+	config.MaxBodyBytes = 0
+	return config
+}
+
+var (
+	// Default is the shared outbound client used when none is injected.
+	Default = New(DefaultConfig())
+	// Streaming relays large bodies, such as media segments, to slow clients.
+	Streaming = New(StreamingConfig())
+)
+
+// Client is a pooled HTTP client that retries transient failures.
+type Client struct {
+	config  Config
+	http    *http.Client
+	limiter *RateLimiter
+}
+
+// New creates a Client with its own pooled transport.
+func New(config Config) *Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+	}
+
+	return &Client{
+		config:  config,
+		http:    &http.Client{Transport: transport},
+		limiter: NewRateLimiter(config.RateLimits, config.MaxQueueWait),
+	}
+}
+
+// Do sends req, retrying on network errors, 429 and 5xx responses with
+// jittered exponential backoff (or the server's Retry-After). Only idempotent
+// requests are retried (see Idempotent), so that e.g. a token exchange or a
+// list update is never sent twice. Requests are paced by the host's rate
+// limit; when the quota is exhausted Do returns a *RateLimitError. The
+// returned body is limited to MaxBodyBytes and must be closed by the caller.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" && c.config.UserAgent != "" {
+		req.Header.Set("User-Agent", c.config.UserAgent)
+	}
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	host := req.URL.Hostname()
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(req.Context(), host); err != nil {
+			return nil, err
+		}
+
+		resp, err = c.attempt(req)
+		if err == nil {
+			c.limiter.Observe(host, resp)
+		}
+
+		if attempt >= c.config.MaxRetries || !Idempotent(req) || !retryable(req.Context(), resp, err) {
+			break
+		}
+		if req.Body != nil && req.GetBody == nil {
+			break // The body cannot be replayed.
+		}
+
+		wait, ok := c.backoff(attempt, resp)
+		if resp != nil {
+			drain(resp.Body)
+		}
+		if !ok {
+			return nil, &RateLimitError{Host: host, RetryAfter: wait}
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok := ParseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			wait = c.config.MaxBackoff
+		}
+		drain(resp.Body)
+		return nil, &RateLimitError{Host: host, RetryAfter: wait}
+	}
+
+	if resp.ContentLength > c.config.MaxBodyBytes && c.config.MaxBodyBytes > 0 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes from %s", ErrBodyTooLarge, resp.ContentLength, req.URL.Host)
+	}
+	return resp, nil
+}
+
+// Get sends a GET request with the given headers.
+func (c *Client) Get(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return c.Do(req)
+}
+
+// GetBytes sends a GET request and returns the body, failing on non-200 responses.
+func (c *Client) GetBytes(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	resp, err := c.Get(ctx, url, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: resp.StatusCode, URL: url}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// PostJSON encodes payload as JSON and POSTs it with the given headers. The
+// request is not retried.
+func (c *Client) PostJSON(ctx context.Context, url string, headers map[string]string, payload interface{}) (*http.Response, error) {
+	req, err := newJSONRequest(ctx, url, headers, payload)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// QueryJSON is PostJSON for requests that only read, such as GraphQL
+// queries, which are retried like a GET.
+func (c *Client) QueryJSON(ctx context.Context, url string, headers map[string]string, payload interface{}) (*http.Response, error) {
+	req, err := newJSONRequest(ctx, url, headers, payload)
+	if err != nil {
+		return nil, err
+	}
+	MarkIdempotent(req)
+	return c.Do(req)
+}
+
+func newJSONRequest(ctx context.Context, url string, headers map[string]string, payload interface{}) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// MarkIdempotent lets Do retry req whatever its method. It sets a nil
+// Idempotency-Key header, which net/http treats the same way and never sends.
+func MarkIdempotent(req *http.Request) {
+	if _, ok := req.Header["Idempotency-Key"]; !ok {
+		req.Header["Idempotency-Key"] = nil
+	}
+}
+
+// Idempotent reports whether req may be sent more than once: GET, HEAD,
+// OPTIONS, PUT and DELETE requests, and those with an Idempotency-Key header
+// (see MarkIdempotent).
+func Idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// attempt performs a single round trip bounded by the host's timeout.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	timeout := c.config.Timeout
+	if hostTimeout, ok := c.config.HostTimeouts[req.URL.Hostname()]; ok {
+		timeout = hostTimeout
+	}
+	if timeout <= 0 {
+		return c.limit(c.http.Do(req))
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout keeps applying while the caller reads the body.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return c.limit(resp, nil)
+}
+
+// limit wraps the response body so that reading past MaxBodyBytes fails.
+func (c *Client) limit(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || c.config.MaxBodyBytes <= 0 {
+		return resp, err
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: c.config.MaxBodyBytes}
+	return resp, nil
+}
+
+// backoff returns how long to wait before the next attempt. It reports false
+// when the server asks us to wait longer than MaxRetryAfter.
+func (c *Client) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if wait, ok := ParseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, wait <= c.config.MaxRetryAfter
+		}
+	}
+
+	ceiling := c.config.BaseBackoff << attempt
+	if ceiling <= 0 || ceiling > c.config.MaxBackoff {
+		ceiling = c.config.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0, true
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))), true
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// retryable reports whether a failed attempt is worth repeating.
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// drain discards the rest of body so the connection can be reused.
+func drain(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
+
+// cancelOnClose releases an attempt's timeout once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// limitedBody fails with ErrBodyTooLarge once more than remaining bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrBodyTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,242 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	config := DefaultConfig()
+	config.BaseBackoff = time.Millisecond
+	config.MaxBackoff = 5 * time.Millisecond
+	config.MaxRetryAfter = time.Second
+	config.RateLimits = nil
+	return config
+}
+
+// flakyServer fails the first failures requests with status, then answers
+// 200 with the request body. It counts every request it sees.
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Header["Idempotency-Key"]; ok {
+			t.Error("Idempotency-Key header was sent upstream")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			if status == http.StatusTooManyRequests {
+				// Without it the limiter holds the host back for a minute.
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestDoRetriesIdempotentRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"server error", http.StatusBadGateway},
+		{"rate limited", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		server, calls := flakyServer(t, 2, tt.status)
+
+		body, err := New(testConfig()).GetBytes(context.Background(), server.URL, nil)
+		if err != nil {
+			t.Errorf("%s: GetBytes: %v", tt.name, err)
+			continue
+		}
+		if calls.Load() != 3 {
+			t.Errorf("%s: %d requests, want 3", tt.name, calls.Load())
+		}
+		if len(body) != 0 {
+			t.Errorf("%s: body = %q, want empty", tt.name, body)
+		}
+	}
+}
+
+func TestDoGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := flakyServer(t, 100, http.StatusInternalServerError)
+
+	_, err := New(testConfig()).GetBytes(context.Background(), server.URL, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusInternalServerError {
+		t.Fatalf("GetBytes error = %v, want a 500 *StatusError", err)
+	}
+	if want := int32(testConfig().MaxRetries + 1); calls.Load() != want {
+		t.Errorf("%d requests, want %d", calls.Load(), want)
+	}
+}
+
+func TestDoDoesNotRetryPosts(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusServiceUnavailable)
+	client := New(testConfig())
+
+	resp, err := client.PostJSON(context.Background(), server.URL, nil, map[string]string{"code": "single-use"})
+	if err != nil {
+		t.Fatalf("PostJSON: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Errorf("PostJSON = %d after %d requests, want 503 after 1", resp.StatusCode, calls.Load())
+	}
+
+	req, _ := http.NewRequest(http.MethodPatch, server.URL, strings.NewReader("progress=3"))
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Do PATCH: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 2 {
+		t.Errorf("PATCH made %d requests, want 1", calls.Load()-1)
+	}
+}
+
+func TestDoRetriesQueriesWithTheirBody(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusInternalServerError)
+
+	resp, err := New(testConfig()).QueryJSON(context.Background(), server.URL, nil, map[string]string{"query": "{ Media { id } }"})
+	if err != nil {
+		t.Fatalf("QueryJSON: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("QueryJSON = %d after %d requests, want 200 after 2", resp.StatusCode, calls.Load())
+	}
+	if string(body) != `{"query":"{ Media { id } }"}` {
+		t.Errorf("replayed body = %q", body)
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	tests := []struct {
+		method string
+		mark   bool
+		want   bool
+	}{
+		{http.MethodGet, false, true},
+		{http.MethodHead, false, true},
+		{http.MethodOptions, false, true},
+		{http.MethodPut, false, true},
+		{http.MethodDelete, false, true},
+		{http.MethodPost, false, false},
+		{http.MethodPatch, false, false},
+		{http.MethodPost, true, true},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, "https://example.com", nil)
+		if tt.mark {
+			MarkIdempotent(req)
+		}
+		if got := Idempotent(req); got != tt.want {
+			t.Errorf("Idempotent(%s, marked %v) = %v, want %v", tt.method, tt.mark, got, tt.want)
+		}
+	}
+}
+
+func TestDoStopsOnLongRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := New(testConfig()).Get(context.Background(), server.URL, nil)
+	var rateLimited *RateLimitError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 120*time.Second {
+		t.Fatalf("Get error = %v, want a *RateLimitError asking for 120s", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("%d requests, want 1", calls.Load())
+	}
+}
+
+func TestDoStopsWhenContextIsDone(t *testing.T) {
+	server, calls := flakyServer(t, 100, http.StatusInternalServerError)
+	config := testConfig()
+	config.BaseBackoff = time.Hour
+	config.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := New(config).Get(ctx, server.URL, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get error = %v, want context.DeadlineExceeded", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("%d requests, want 1", calls.Load())
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	config := testConfig()
+	config.BaseBackoff = 100 * time.Millisecond
+	config.MaxBackoff = time.Second
+	config.MaxRetryAfter = 10 * time.Second
+	client := New(config)
+
+	// Jitter stays below the doubled ceiling, capped at MaxBackoff.
+	for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 50; i++ {
+			wait, ok := client.backoff(attempt, nil)
+			if !ok || wait < 0 || wait >= ceiling {
+				t.Fatalf("backoff(%d) = %v, %v; want [0, %v)", attempt, wait, ok, ceiling)
+			}
+		}
+	}
+
+	retryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+	if wait, ok := client.backoff(0, retryAfter("3")); wait != 3*time.Second || !ok {
+		t.Errorf("backoff with Retry-After 3 = %v, %v; want 3s, true", wait, ok)
+	}
+	if wait, ok := client.backoff(0, retryAfter("60")); wait != time.Minute || ok {
+		t.Errorf("backoff with Retry-After 60 = %v, %v; want 1m, false", wait, ok)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"42", 42 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", 0, true}, // In the past
+	}
+	for _, tt := range tests {
+		got, ok := ParseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got, ok := ParseRetryAfter(future); !ok || got <= 0 || got > time.Minute {
+		t.Errorf("ParseRetryAfter(%q) = %v, %v; want about 1m", future, got, ok)
+	}
+}
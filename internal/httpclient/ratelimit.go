@@ -0,0 +1,278 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is matched by every *RateLimitError, e.g. errors.Is(err, ErrRateLimited).
+var ErrRateLimited = errors.New("upstream rate limited")
+
+// RateLimitError reports that an upstream host refused, or would have
+// refused, a request because its quota is exhausted.
+type RateLimitError struct {
+	Host       string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s, retry after %s", ErrRateLimited, e.Host, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RetryAfterSeconds returns RetryAfter rounded up to whole seconds, at least 1.
+func (e *RateLimitError) RetryAfterSeconds() int {
+	seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// Limit is a token bucket allowing Requests per Per, with bursts of up to Burst.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// RateLimiter keeps one token bucket per upstream host. Hosts without a
+// configured Limit are unlimited until they answer with a rate limit, and
+// only have a bucket while they are blocked, so the many hosts the client
+// streams from do not accumulate.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limits   map[string]Limit
+	buckets  map[string]*bucket
+	maxQueue time.Duration
+}
+
+// NewRateLimiter creates a limiter for the given per-host limits. Requests
+// wait for a token for at most maxQueue; beyond that they fail fast with a
+// *RateLimitError.
+func NewRateLimiter(limits map[string]Limit, maxQueue time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limits:   limits,
+		buckets:  make(map[string]*bucket),
+		maxQueue: maxQueue,
+	}
+}
+
+// Wait blocks until a request to host may be sent, ctx is done, or the wait
+// would exceed the queue limit.
+func (r *RateLimiter) Wait(ctx context.Context, host string) error {
+	b := r.bucket(host, time.Time{})
+	if b == nil {
+		return nil
+	}
+	wait, ok := b.reserve(time.Now(), r.maxQueue)
+	if !ok {
+		return &RateLimitError{Host: host, RetryAfter: wait}
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe adapts host's bucket to the X-RateLimit-Remaining, X-RateLimit-Reset
+// and Retry-After headers of resp.
+func (r *RateLimiter) Observe(host string, resp *http.Response) {
+	now := time.Now()
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	hasRemaining := err == nil
+	var reset time.Time
+	if ts, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(ts, 0)
+	}
+
+	// When the host is blocked until; unlimited hosts need no bucket otherwise.
+	var until time.Time
+	if hasRemaining && remaining <= 0 && reset.After(now) {
+		until = reset
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok := ParseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			wait = time.Minute
+		}
+		if now.Add(wait).After(until) {
+			until = now.Add(wait)
+		}
+	}
+
+	b := r.bucket(host, until)
+	if b != nil && hasRemaining {
+		b.observeRemaining(now, remaining, reset)
+	}
+}
+
+// bucket returns the token bucket for host, blocked until the given time if
+// it is not zero. Buckets of limited hosts are created on first use. An
+// unlimited host only has one while it is blocked; nil means it is not.
+func (r *RateLimiter) bucket(host string, until time.Time) *bucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	limit, limited := r.limits[host]
+	b, ok := r.buckets[host]
+	switch {
+	case ok && !limited && until.IsZero() && b.idle(now):
+		delete(r.buckets, host)
+		return nil
+	case ok:
+	case !limited && until.IsZero():
+		return nil
+	default:
+		if !limited {
+			// Drop the buckets of unlimited hosts no longer blocked, which
+			// are otherwise only deleted when next used.
+			for key, other := range r.buckets {
+				if _, ok := r.limits[key]; !ok && other.idle(now) {
+					delete(r.buckets, key)
+				}
+			}
+		}
+		b = newBucket(limit)
+		r.buckets[host] = b
+	}
+
+	if !until.IsZero() {
+		b.block(until)
+	}
+	return b
+}
+
+// bucket is a token bucket that can additionally be blocked until a point in time.
+type bucket struct {
+	mu           sync.Mutex
+	limited      bool
+	capacity     float64
+	rate         float64 // tokens per second
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func newBucket(limit Limit) *bucket {
+	b := &bucket{last: time.Now()}
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return b
+	}
+
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Requests
+	}
+
+	b.limited = true
+	b.capacity = float64(burst)
+	b.tokens = b.capacity
+	b.rate = float64(limit.Requests) / limit.Per.Seconds()
+	return b
+}
+
+// idle reports whether an unlimited bucket is no longer blocked at now.
+func (b *bucket) idle(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.limited && !now.Before(b.blockedUntil)
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it. It reports false, without taking a token, when the wait would
+// exceed maxWait.
+func (b *bucket) reserve(now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var wait time.Duration
+	if now.Before(b.blockedUntil) {
+		wait = b.blockedUntil.Sub(now)
+	}
+
+	if b.limited {
+		b.refill(now)
+		if b.tokens < 1 {
+			tokenWait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+			if tokenWait > wait {
+				wait = tokenWait
+			}
+		}
+	}
+
+	if wait > maxWait {
+		return wait, false
+	}
+
+	if b.limited {
+		b.tokens--
+	}
+	return wait, true
+}
+
+// refill adds the tokens accrued since the last update. Callers must hold mu.
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// observeRemaining lowers the available tokens to what the upstream reports.
+// When the quota is used up, the bucket is blocked until reset (or for the
+// time it takes to earn one token).
+func (b *bucket) observeRemaining(now time.Time, remaining int, reset time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limited {
+		b.refill(now)
+		if float64(remaining) < b.tokens {
+			b.tokens = float64(remaining)
+		}
+	}
+
+	if remaining > 0 {
+		return
+	}
+	if reset.After(now) {
+		b.blockUntilLocked(reset)
+	} else if b.limited {
+		b.blockUntilLocked(now.Add(time.Duration(float64(time.Second) / b.rate)))
+	}
+}
+
+// block rejects or delays requests until the given time.
+func (b *bucket) block(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.blockUntilLocked(until)
+}
+
+func (b *bucket) blockUntilLocked(until time.Time) {
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+}
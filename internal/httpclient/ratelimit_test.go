@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func tooManyRequests(retryAfter string) *http.Response {
+	return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {retryAfter}}}
+}
+
+func TestRateLimiterKeepsNoBucketsForUnlimitedHosts(t *testing.T) {
+	limiter := NewRateLimiter(map[string]Limit{"limited.test": {Requests: 10, Per: time.Second}}, time.Second)
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{"X-Ratelimit-Remaining": {"5"}}}
+
+	for i := 0; i < 100; i++ {
+		host := fmt.Sprintf("cdn%d.test", i)
+		if err := limiter.Wait(context.Background(), host); err != nil {
+			t.Fatalf("Wait(%s) error = %v", host, err)
+		}
+		limiter.Observe(host, ok)
+	}
+	if err := limiter.Wait(context.Background(), "limited.test"); err != nil {
+		t.Fatalf("Wait(limited.test) error = %v", err)
+	}
+
+	if len(limiter.buckets) != 1 {
+		t.Errorf("limiter has %d buckets, want only limited.test's", len(limiter.buckets))
+	}
+}
+
+func TestRateLimiterBlocksUnlimitedHostsUntilRetryAfter(t *testing.T) {
+	limiter := NewRateLimiter(nil, 0)
+
+	limiter.Observe("cdn.test", tooManyRequests("60"))
+	err := limiter.Wait(context.Background(), "cdn.test")
+	var rateLimited *RateLimitError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter <= 0 {
+		t.Fatalf("Wait() while blocked error = %v, want a RateLimitError", err)
+	}
+
+	// Once the block is over the bucket is dropped, on use or by a sweep.
+	limiter.Observe("other.test", tooManyRequests("0"))
+	limiter.buckets["cdn.test"].blockedUntil = time.Now()
+	if err := limiter.Wait(context.Background(), "other.test"); err != nil {
+		t.Fatalf("Wait() after the block error = %v", err)
+	}
+	limiter.Observe("third.test", tooManyRequests("60"))
+
+	if _, ok := limiter.buckets["cdn.test"]; ok {
+		t.Error("bucket of cdn.test kept after its block ended")
+	}
+	if _, ok := limiter.buckets["other.test"]; ok {
+		t.Error("bucket of other.test kept after its block ended")
+	}
+	if _, ok := limiter.buckets["third.test"]; !ok {
+		t.Error("bucket of the blocked third.test was dropped")
+	}
+}
@@ -1,46 +1,54 @@
 package mapping
 
 import (
-	"aniverse/internal/provider/anilist"
-	"aniverse/internal/provider/gogoanime"
-	"aniverse/internal/provider/mal"
 	"aniverse/internal/types"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 )
 
 // EpisodesResult contains the combined list of sub and dub episodes.
 type EpisodesResult struct {
+	Provider string
 	Episodes []types.Episode
 }
 
-func GetEpisodes(anilistID string) (*EpisodesResult, error) {
-	gogoAnimeMap, err := GetGogoAnimeMap(anilistID)
+// GetEpisodes lists the episodes of an anime from the first episode provider that carries it.
+func (m *Mapper) GetEpisodes(ctx context.Context, anilistID string) (*EpisodesResult, error) {
+	providerMap, err := m.FindMap(ctx, anilistID)
+	if errors.Is(err, ErrNoMapping) {
+		return &EpisodesResult{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	gogoAnimeProvider := gogoanime.NewGogoAnime()
-	aniListProvider := anilist.NewAniListBase()
-	animeInfo, err := aniListProvider.GetMedia(anilistID)
+	meta := m.registry.Meta()
+	if meta == nil {
+		return nil, errors.New("no metadata provider registered")
+	}
+
+	animeInfo, err := meta.GetMedia(ctx, anilistID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get anime info from AniList: %v", err)
+		return nil, fmt.Errorf("failed to get anime info from %s: %w", meta.ID(), err)
 	}
 
 	var subEpisodes []types.Episode
 	var dubEpisodes []types.Episode
 
 	// Fetch subbed episodes
-	if gogoAnimeMap.Sub != nil {
-		subEpisodes, err = gogoAnimeProvider.FetchEpisodes(gogoAnimeMap.Sub.ID)
+	if providerMap.Sub != nil {
+		subEpisodes, err = providerMap.Provider.FetchEpisodes(ctx, providerMap.Sub.ID)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	// Fetch dubbed episodes
-	if gogoAnimeMap.Dub != nil {
-		dubEpisodes, err = gogoAnimeProvider.FetchEpisodes(gogoAnimeMap.Dub.ID)
+	if providerMap.Dub != nil {
+		dubEpisodes, err = providerMap.Provider.FetchEpisodes(ctx, providerMap.Dub.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -49,8 +57,7 @@ func GetEpisodes(anilistID string) (*EpisodesResult, error) {
 	// Fetch episode titles from MAL
 	var malEpisodes map[int]string
 	if animeInfo.IDMal != "" {
-		mal := mal.NewMyAnimeList()
-		malEpisodes, err = mal.GetEpisodeTitles(animeInfo.IDMal, animeInfo.Title.English, 0)
+		malEpisodes, err = m.myanimelist.GetEpisodeTitles(ctx, animeInfo.IDMal, animeInfo.Title.English, 0)
 		if err != nil {
 			log.Printf("Error scraping episode titles from MyAnimeList for ID %s: %v", animeInfo.IDMal, err)
 		}
@@ -67,6 +74,7 @@ func GetEpisodes(anilistID string) (*EpisodesResult, error) {
 			EpisodeTitle: subEp.EpisodeTitle,
 			IsFiller:     subEp.IsFiller,
 			Img:          subEp.Img,
+			HasSub:       true,
 			HasDub:       false,        // No dub by default
 			Source:       subEp.Source, // Initialize with subbed source
 		}
@@ -114,14 +122,18 @@ func GetEpisodes(anilistID string) (*EpisodesResult, error) {
 		}
 	}
 
-	// Convert map to slice
+	// Convert map to slice, in episode order
 	var combinedEpisodes []types.Episode
 	for _, episode := range episodeMap {
 		combinedEpisodes = append(combinedEpisodes, episode)
 	}
+	sort.Slice(combinedEpisodes, func(i, j int) bool {
+		return combinedEpisodes[i].Number < combinedEpisodes[j].Number
+	})
 
 	// Return combined episodes in an EpisodesResult struct
 	return &EpisodesResult{
+		Provider: providerMap.Provider.ID(),
 		Episodes: combinedEpisodes,
 	}, nil
 }
@@ -0,0 +1,169 @@
+package mapping
+
+import (
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+	"aniverse/internal/util"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ChapterMapResult holds the entry a chapter provider has for a manga.
+type ChapterMapResult struct {
+	Provider provider.ChapterProvider
+	Manga    *types.MangaInfo
+}
+
+// ChaptersResult contains the chapters of a manga and where they came from.
+type ChaptersResult struct {
+	Provider string
+	Chapters []types.Chapter
+}
+
+// FindChapterMap returns the mapping of the first registered chapter provider
+// that carries the manga, in registration order.
+func (m *Mapper) FindChapterMap(ctx context.Context, anilistID string) (*ChapterMapResult, error) {
+	var lastErr error
+	for _, p := range m.registry.ChapterProviders() {
+		result, err := m.GetChapterMap(ctx, p, anilistID)
+		if err != nil {
+			log.Printf("Error mapping AniList manga ID %s on %s: %v", anilistID, p.ID(), err)
+			lastErr = err
+			continue
+		}
+		if result.Manga != nil {
+			return result, nil
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNoMapping
+}
+
+// GetChapterMap returns the entry p has for an AniList manga ID, preferring a
+// persisted mapping over a fresh search. The provider's ID is persisted as
+// the record's Sub entry. As in GetMap, pinned mappings are always used and
+// computed ones are recomputed once stale (having no dub, after a day), and
+// kept if that fails.
+func (m *Mapper) GetChapterMap(ctx context.Context, p provider.ChapterProvider, anilistID string) (*ChapterMapResult, error) {
+	var stored *Record
+	if m.store != nil {
+		record, err := m.store.Get(p.ID(), anilistID)
+		if err != nil {
+			log.Printf("Error reading stored mapping for AniList ID %s: %v", anilistID, err)
+		} else if record != nil && record.Sub != "" {
+			if !record.Stale(time.Now()) {
+				return chapterResultFromRecord(p, record), nil
+			}
+			stored = record
+		}
+	}
+
+	result, score, err := m.computeChapterMap(ctx, p, anilistID)
+	if err != nil {
+		if stored != nil {
+			log.Printf("Error recomputing mapping for AniList ID %s, using the stored one: %v", anilistID, err)
+			return chapterResultFromRecord(p, stored), nil
+		}
+		return nil, err
+	}
+	if stored != nil && result.Manga == nil {
+		// The provider no longer finds the manga; keep what worked.
+		return chapterResultFromRecord(p, stored), nil
+	}
+
+	if m.store != nil && result.Manga != nil {
+		record := Record{AniListID: anilistID, Sub: result.Manga.ID, Score: score}
+		if err := m.store.Put(p.ID(), record); err != nil {
+			log.Printf("Error persisting mapping for AniList ID %s: %v", anilistID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// chapterResultFromRecord turns a stored mapping into the entry GetChapterMap
+// returns.
+func chapterResultFromRecord(p provider.ChapterProvider, record *Record) *ChapterMapResult {
+	return &ChapterMapResult{
+		Provider: p,
+		Manga:    &types.MangaInfo{ID: record.Sub, Type: types.TypeManga},
+	}
+}
+
+// computeChapterMap searches p and picks the closest title, returning its
+// match score.
+func (m *Mapper) computeChapterMap(ctx context.Context, p provider.ChapterProvider, anilistID string) (*ChapterMapResult, float64, error) {
+	meta := m.registry.Manga()
+	if meta == nil {
+		return nil, 0, errors.New("no manga metadata provider registered")
+	}
+
+	mangaInfo, err := meta.GetManga(ctx, anilistID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get manga info from %s: %w", meta.ID(), err)
+	}
+	title := mangaInfo.Title
+
+	searchTitle := util.Sanitize(title.English)
+	if searchTitle == "" {
+		searchTitle = util.Sanitize(title.Romaji)
+	}
+
+	searchResults, err := p.SearchManga(ctx, searchTitle)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search %s: %w", p.ID(), err)
+	}
+
+	var providerTitles []string
+	for _, result := range searchResults {
+		providerTitles = append(providerTitles, result.Title.Romaji)
+	}
+
+	bestTitle := util.FindOriginalTitle(title, providerTitles)
+
+	var best *types.MangaInfo
+	for i := range searchResults {
+		if searchResults[i].Title.Romaji == bestTitle {
+			best = &searchResults[i]
+			break
+		}
+	}
+
+	var score float64
+	if best != nil {
+		score = util.MatchScore(title, bestTitle)
+	}
+
+	return &ChapterMapResult{
+		Provider: p,
+		Manga:    best,
+	}, score, nil
+}
+
+// GetChapters lists the chapters of a manga from the first chapter provider
+// that carries it.
+func (m *Mapper) GetChapters(ctx context.Context, anilistID string) (*ChaptersResult, error) {
+	chapterMap, err := m.FindChapterMap(ctx, anilistID)
+	if errors.Is(err, ErrNoMapping) {
+		return &ChaptersResult{Chapters: []types.Chapter{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chapters, err := chapterMap.Provider.FetchChapters(ctx, chapterMap.Manga.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChaptersResult{
+		Provider: chapterMap.Provider.ID(),
+		Chapters: chapters,
+	}, nil
+}
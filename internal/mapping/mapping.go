@@ -1,17 +1,44 @@
 package mapping
 
 import (
-	"aniverse/internal/provider/anilist"
-	"aniverse/internal/provider/gogoanime"
+	"aniverse/internal/apperror"
+	"aniverse/internal/provider"
+	"aniverse/internal/provider/mal"
 	"aniverse/internal/types"
 	"aniverse/internal/util"
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
+	"time"
 )
 
-type GogoAnimeMapResult struct {
-	Sub *types.AnimeInfo
-	Dub *types.AnimeInfo
+// ErrNoMapping is returned when no registered episode provider carries the anime.
+var ErrNoMapping = apperror.New(apperror.KindMappingNotFound, "", "no provider mapping found")
+
+// Mapper maps AniList entries onto the registered episode providers.
+type Mapper struct {
+	registry    *provider.Registry
+	myanimelist *mal.MyAnimeList
+	store       *Store
+}
+
+// NewMapper creates a Mapper that uses the providers in registry. When store is
+// non-nil, computed mappings are persisted and reused on later requests.
+func NewMapper(registry *provider.Registry, myanimelist *mal.MyAnimeList, store *Store) *Mapper {
+	return &Mapper{
+		registry:    registry,
+		myanimelist: myanimelist,
+		store:       store,
+	}
+}
+
+// MapResult holds the sub and dub entries an episode provider has for an anime.
+type MapResult struct {
+	Provider provider.EpisodeProvider
+	Sub      *types.AnimeInfo
+	Dub      *types.AnimeInfo
 }
 
 type AniListIDResponse struct {
@@ -22,15 +49,86 @@ type AniListIDResponse struct {
 	} `json:"data"`
 }
 
-func GetGogoAnimeMap(anilistID string) (*GogoAnimeMapResult, error) {
-	// Initialize providers
-	aniListProvider := anilist.NewAniListBase()
-	gogoAnimeProvider := gogoanime.NewGogoAnime()
+// FindMap returns the mapping of the first registered episode provider that
+// carries the anime, in registration order.
+func (m *Mapper) FindMap(ctx context.Context, anilistID string) (*MapResult, error) {
+	var lastErr error
+	for _, p := range m.registry.EpisodeProviders() {
+		result, err := m.GetMap(ctx, p, anilistID)
+		if err != nil {
+			log.Printf("Error mapping AniList ID %s on %s: %v", anilistID, p.ID(), err)
+			lastErr = err
+			continue
+		}
+		if result.Sub != nil || result.Dub != nil {
+			return result, nil
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNoMapping
+}
+
+// GetMap returns the sub/dub entries p has for an AniList ID, preferring a
+// persisted mapping over a fresh search. Pinned mappings are always used;
+// computed ones are recomputed once stale, and kept if that fails.
+func (m *Mapper) GetMap(ctx context.Context, p provider.EpisodeProvider, anilistID string) (*MapResult, error) {
+	var stored *Record
+	if m.store != nil {
+		record, err := m.store.Get(p.ID(), anilistID)
+		if err != nil {
+			log.Printf("Error reading stored mapping for AniList ID %s: %v", anilistID, err)
+		} else if record != nil {
+			if !record.Stale(time.Now()) {
+				return resultFromRecord(p, record), nil
+			}
+			stored = record
+		}
+	}
 
-	// Get anime title from AniList
-	animeInfo, err := aniListProvider.GetMedia(anilistID)
+	result, score, err := m.computeMap(ctx, p, anilistID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get anime info from AniList: %v", err)
+		if stored != nil {
+			log.Printf("Error recomputing mapping for AniList ID %s, using the stored one: %v", anilistID, err)
+			return resultFromRecord(p, stored), nil
+		}
+		return nil, err
+	}
+	if stored != nil && result.Sub == nil && result.Dub == nil {
+		// The provider no longer finds the anime; keep what worked.
+		return resultFromRecord(p, stored), nil
+	}
+
+	if m.store != nil && (result.Sub != nil || result.Dub != nil) {
+		record := Record{AniListID: anilistID, Score: score}
+		if result.Sub != nil {
+			record.Sub = result.Sub.ID
+		}
+		if result.Dub != nil {
+			record.Dub = result.Dub.ID
+		}
+		if err := m.store.Put(p.ID(), record); err != nil {
+			log.Printf("Error persisting mapping for AniList ID %s: %v", anilistID, err)
+		}
+	}
+
+	return result, nil
+}
+
+// computeMap searches p and picks the closest titles, returning the match
+// score of the chosen sub (or dub) entry.
+func (m *Mapper) computeMap(ctx context.Context, p provider.EpisodeProvider, anilistID string) (*MapResult, float64, error) {
+	meta := m.registry.Meta()
+	if meta == nil {
+		return nil, 0, errors.New("no metadata provider registered")
+	}
+
+	// Get anime title from the metadata provider
+	animeInfo, err := meta.GetMedia(ctx, anilistID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get anime info from %s: %w", meta.ID(), err)
 	}
 
 	title := animeInfo.Title
@@ -48,29 +146,29 @@ func GetGogoAnimeMap(anilistID string) (*GogoAnimeMapResult, error) {
 		searchTitle = util.Sanitize(title.Romaji)
 	}
 
-	// Search GogoAnime with sanitized title
-	searchResults, err := gogoAnimeProvider.Search(searchTitle)
+	// Search the provider with sanitized title
+	searchResults, err := p.Search(ctx, searchTitle)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search GogoAnime: %v", err)
+		return nil, 0, fmt.Errorf("failed to search %s: %w", p.ID(), err)
 	}
 
 	// Collect titles from search results
 	var (
-		gogoTitles    []string
-		gogoDubTitles []string
+		providerTitles    []string
+		providerDubTitles []string
 	)
 
 	for _, result := range searchResults {
-		gogoTitle := result.Title.Romaji
-		gogoTitles = append(gogoTitles, gogoTitle)
-		if strings.Contains(strings.ToLower(gogoTitle), "(dub)") {
-			gogoDubTitles = append(gogoDubTitles, gogoTitle)
+		providerTitle := result.Title.Romaji
+		providerTitles = append(providerTitles, providerTitle)
+		if strings.Contains(strings.ToLower(providerTitle), "(dub)") {
+			providerDubTitles = append(providerDubTitles, providerTitle)
 		}
 	}
 
 	// Find the best matching titles
-	bestSubTitle := util.FindOriginalTitle(title, gogoTitles)
-	bestDubTitle := util.FindOriginalTitle(titleDub, gogoDubTitles)
+	bestSubTitle := util.FindOriginalTitle(title, providerTitles)
+	bestDubTitle := util.FindOriginalTitle(titleDub, providerDubTitles)
 
 	// Find the corresponding AnimeInfo objects
 	var (
@@ -87,8 +185,28 @@ func GetGogoAnimeMap(anilistID string) (*GogoAnimeMapResult, error) {
 		}
 	}
 
-	return &GogoAnimeMapResult{
-		Sub: bestSub,
-		Dub: bestDub,
-	}, nil
+	var score float64
+	if bestSub != nil {
+		score = util.MatchScore(title, bestSubTitle)
+	} else if bestDub != nil {
+		score = util.MatchScore(titleDub, bestDubTitle)
+	}
+
+	return &MapResult{
+		Provider: p,
+		Sub:      bestSub,
+		Dub:      bestDub,
+	}, score, nil
+}
+
+// resultFromRecord rebuilds a MapResult from a persisted mapping.
+func resultFromRecord(p provider.EpisodeProvider, record *Record) *MapResult {
+	result := &MapResult{Provider: p}
+	if record.Sub != "" {
+		result.Sub = &types.AnimeInfo{ID: record.Sub, Type: types.TypeAnime}
+	}
+	if record.Dub != "" {
+		result.Dub = &types.AnimeInfo{ID: record.Dub, Type: types.TypeAnime}
+	}
+	return result
 }
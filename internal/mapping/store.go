@@ -0,0 +1,117 @@
+package mapping
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Record is a persisted mapping from an AniList ID to a provider's sub/dub slugs.
+type Record struct {
+	AniListID string    `json:"anilistId"`
+	Sub       string    `json:"sub,omitempty"`
+	Dub       string    `json:"dub,omitempty"`
+	Score     float64   `json:"score"`
+	Pinned    bool      `json:"pinned"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Computed mappings are recomputed once they are older than these, so that
+// a dub released later is picked up and a wrong match does not stick. Pinned
+// mappings never expire.
+const (
+	mappingTTL        = 7 * 24 * time.Hour
+	partialMappingTTL = 24 * time.Hour // Sub or dub missing
+)
+
+// Stale reports whether a computed mapping should be recomputed at now.
+func (r *Record) Stale(now time.Time) bool {
+	if r.Pinned {
+		return false
+	}
+	ttl := mappingTTL
+	if r.Sub == "" || r.Dub == "" {
+		ttl = partialMappingTTL
+	}
+	return now.Sub(r.UpdatedAt) > ttl
+}
+
+// Store persists mappings in an embedded BoltDB file, one bucket per provider.
+type Store struct {
+	db *bolt.DB
+}
+
+// OpenStore opens (or creates) the mapping database at path.
+func OpenStore(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Get returns the mapping stored for anilistID, or nil if there is none.
+func (s *Store) Get(providerID string, anilistID string) (*Record, error) {
+	var record *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(providerID))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(anilistID))
+		if data == nil {
+			return nil
+		}
+		record = &Record{}
+		return json.Unmarshal(data, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Put stores record, replacing any existing mapping for the same AniList ID.
+func (s *Store) Put(providerID string, record Record) error {
+	if record.UpdatedAt.IsZero() {
+		record.UpdatedAt = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(providerID))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(record.AniListID), data)
+	})
+}
+
+// Delete removes the mapping for anilistID so that it is recomputed on next use.
+func (s *Store) Delete(providerID string, anilistID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(providerID))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(anilistID))
+	})
+}
+
+// Close releases the database file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
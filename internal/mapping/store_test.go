@@ -0,0 +1,56 @@
+package mapping
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordStale(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		record Record
+		want   bool
+	}{
+		{"fresh", Record{Sub: "a", Dub: "a-dub", UpdatedAt: now.Add(-6 * 24 * time.Hour)}, false},
+		{"expired", Record{Sub: "a", Dub: "a-dub", UpdatedAt: now.Add(-8 * 24 * time.Hour)}, true},
+		{"dub missing, fresh", Record{Sub: "a", UpdatedAt: now.Add(-time.Hour)}, false},
+		{"dub missing, expired", Record{Sub: "a", UpdatedAt: now.Add(-25 * time.Hour)}, true},
+		{"sub missing, expired", Record{Dub: "a-dub", UpdatedAt: now.Add(-25 * time.Hour)}, true},
+		{"pinned", Record{Sub: "a", Pinned: true, UpdatedAt: now.Add(-365 * 24 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.record.Stale(now); got != tt.want {
+			t.Errorf("%s: Stale = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "mappings.db"))
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("gogoanime", Record{AniListID: "21", Sub: "one-piece", Pinned: true}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	record, err := store.Get("gogoanime", "21")
+	if err != nil || record == nil {
+		t.Fatalf("Get = %v, %v; want the record", record, err)
+	}
+	if record.Sub != "one-piece" || !record.Pinned || record.UpdatedAt.IsZero() {
+		t.Errorf("Get = %+v, want the pinned record with UpdatedAt set", record)
+	}
+
+	if err := store.Delete("gogoanime", "21"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if record, err := store.Get("gogoanime", "21"); err != nil || record != nil {
+		t.Errorf("Get after Delete = %v, %v; want nil, nil", record, err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package openapi describes the HTTP API as an OpenAPI 3 document. The JSON
+// shapes are derived from the types package, so they follow the structs, and
+// Check makes sure the documented routes are the ones the server registers.
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Document is an OpenAPI 3.0 document.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type Operation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// route is a documented operation under its fiber method and path.
+type route struct {
+	method    string
+	path      string
+	operation Operation
+}
+
+var (
+	specOnce sync.Once
+	spec     *Document
+)
+
+// Spec returns the OpenAPI document of the API.
+func Spec() *Document {
+	specOnce.Do(func() {
+		schemas := components{}
+		spec = &Document{
+			OpenAPI: "3.0.3",
+			Info: Info{
+				Title:       "Aniverse",
+				Version:     "1.0.0",
+				Description: "Anime metadata from AniList, episodes and streams from the mapped providers.",
+			},
+			Paths: map[string]map[string]Operation{},
+			Components: Components{
+				Schemas: schemas,
+				SecuritySchemes: map[string]SecurityScheme{
+					"adminToken": {Type: "http", Scheme: "bearer"},
+				},
+			},
+		}
+		for _, r := range mounted(schemas) {
+			path := openAPIPath(r.path)
+			if spec.Paths[path] == nil {
+				spec.Paths[path] = map[string]Operation{}
+			}
+			spec.Paths[path][strings.ToLower(r.method)] = r.operation
+		}
+	})
+	return spec
+}
+
+// Check reports the routes that are registered but not documented, or
+// documented but not registered. HEAD routes fiber adds for GET are skipped.
+func Check(registered []fiber.Route) error {
+	documented := map[string]bool{}
+	for _, r := range mounted(components{}) {
+		documented[r.method+" "+r.path] = true
+	}
+
+	var problems []string
+	for _, r := range registered {
+		if r.Method == fiber.MethodHead {
+			continue
+		}
+		key := r.Method + " " + r.Path
+		if documented[key] {
+			delete(documented, key)
+			continue
+		}
+		problems = append(problems, "undocumented route "+key)
+	}
+	for key := range documented {
+		problems = append(problems, "documented route "+key+" is not registered")
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("OpenAPI document does not match the routes: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// apiPrefix is where the current version of the JSON API is mounted.
+const apiPrefix = "/v1"
+
+// mounted returns every route as the server mounts it: the API routes under
+// apiPrefix, with their unversioned aliases marked deprecated, then the rest.
+func mounted(c components) []route {
+	c.addErrorSchemas()
+
+	var result []route
+	for _, r := range apiRoutes(c) {
+		alias := r
+		alias.operation.Deprecated = true
+		alias.operation.Description = strings.TrimSpace("Alias of " + apiPrefix + r.path + ". " + r.operation.Description)
+
+		r.path = apiPrefix + r.path
+		result = append(result, r, alias)
+	}
+	return append(result, routes(c)...)
+}
+
+var pathParamPattern = regexp.MustCompile(`:(\w+)`)
+
+// openAPIPath turns a fiber path such as /info/:id into /info/{id}.
+func openAPIPath(path string) string {
+	return pathParamPattern.ReplaceAllString(path, "{$1}")
+}
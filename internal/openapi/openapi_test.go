@@ -0,0 +1,280 @@
+package openapi_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"aniverse/internal/controller"
+	"aniverse/internal/openapi"
+)
+
+// readElsewhere lists the query parameters controller functions read other
+// than through c.Query.
+var readElsewhere = map[string][]string{
+	"proxyTarget": {"url", "h", "exp", "sig"}, // proxy.Verify
+}
+
+// getOnly lists the handlers that read their query parameters on GET only.
+var getOnly = map[string]bool{
+	"GraphQL": true,
+}
+
+// app builds the server's real route table.
+func app() *fiber.App {
+	app := fiber.New()
+	new(controller.BaseController).Routes(app)
+	return app
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	if err := openapi.Check(app().GetRoutes(true)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryParametersMatchHandlers(t *testing.T) {
+	reads := parseQueryReads(t, "../controller")
+	spec := openapi.Spec()
+
+	for _, route := range app().GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		handler := handlerName(route.Handlers[len(route.Handlers)-1])
+
+		var read []string
+		if route.Method == fiber.MethodGet || !getOnly[handler] {
+			read = reads.of(handler)
+		}
+
+		var documented []string
+		operation := spec.Paths[openAPIPath(route.Path)][strings.ToLower(route.Method)]
+		for _, parameter := range operation.Parameters {
+			if parameter.In == "query" {
+				documented = append(documented, parameter.Name)
+			}
+		}
+		sort.Strings(documented)
+
+		if strings.Join(read, ",") != strings.Join(documented, ",") {
+			t.Errorf("%s %s: %s reads %v, documented %v", route.Method, route.Path, handler, read, documented)
+		}
+	}
+}
+
+// handlerName returns the name of the BaseController method h is bound to.
+func handlerName(h fiber.Handler) string {
+	name := runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// openAPIPath turns a fiber path such as /info/:id into /info/{id}.
+func openAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// queryReads finds the query parameters each function of a package reads
+// from its *fiber.Ctx, named c, including through the functions it passes
+// c to.
+type queryReads struct {
+	funcs map[string]*ast.FuncDecl
+	cache map[string][]string
+}
+
+func parseQueryReads(t *testing.T, dir string) *queryReads {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reads := &queryReads{funcs: map[string]*ast.FuncDecl{}, cache: map[string][]string{}}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", path, err)
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				reads.funcs[fn.Name.Name] = fn
+			}
+		}
+	}
+	return reads
+}
+
+// of returns the sorted parameters function name reads.
+func (r *queryReads) of(name string) []string {
+	if read, ok := r.cache[name]; ok {
+		return read
+	}
+	r.cache[name] = nil // Breaks recursion.
+
+	fn := r.funcs[name]
+	if fn == nil || fn.Body == nil {
+		return nil
+	}
+
+	// Loops such as `for name := range map[string]*int{"year": ...}` read
+	// the literals they range over through the loop variable.
+	ranged := map[string][]string{}
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		loop, ok := node.(*ast.RangeStmt)
+		if !ok {
+			return true
+		}
+		for _, variable := range []ast.Expr{loop.Key, loop.Value} {
+			if ident, ok := variable.(*ast.Ident); ok {
+				ranged[ident.Name] = stringLiterals(loop.X)
+			}
+		}
+		return true
+	})
+
+	set := map[string]bool{}
+	for _, name := range readElsewhere[name] {
+		set[name] = true
+	}
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		if isQueryCall(call) {
+			switch arg := call.Args[0].(type) {
+			case *ast.BasicLit:
+				set[unquote(arg)] = true
+			case *ast.Ident:
+				for _, name := range ranged[arg.Name] {
+					set[name] = true
+				}
+			}
+			return true
+		}
+
+		if !passesCtx(call) {
+			return true
+		}
+		var callee string
+		switch fun := call.Fun.(type) {
+		case *ast.Ident:
+			callee = fun.Name
+		case *ast.SelectorExpr:
+			callee = fun.Sel.Name
+		}
+		for _, name := range r.of(callee) {
+			set[name] = true
+		}
+		// Helpers such as listParam(c, "genres") read the names passed in.
+		if readsParameter(r.funcs[callee]) {
+			for _, arg := range call.Args {
+				if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					set[unquote(lit)] = true
+				}
+			}
+		}
+		return true
+	})
+
+	read := make([]string, 0, len(set))
+	for name := range set {
+		read = append(read, name)
+	}
+	sort.Strings(read)
+	r.cache[fn.Name.Name] = read
+	return read
+}
+
+// isQueryCall reports whether call is c.Query(...).
+func isQueryCall(call *ast.CallExpr) bool {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Query" || len(call.Args) == 0 {
+		return false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	return ok && ident.Name == "c"
+}
+
+// passesCtx reports whether call passes c on.
+func passesCtx(call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		if ident, ok := arg.(*ast.Ident); ok && ident.Name == "c" {
+			return true
+		}
+	}
+	return false
+}
+
+// readsParameter reports whether fn reads a query parameter named by one of
+// its own string parameters.
+func readsParameter(fn *ast.FuncDecl) bool {
+	if fn == nil || fn.Body == nil {
+		return false
+	}
+	parameters := map[string]bool{}
+	for _, field := range fn.Type.Params.List {
+		if ident, ok := field.Type.(*ast.Ident); ok && ident.Name == "string" {
+			for _, name := range field.Names {
+				parameters[name.Name] = true
+			}
+		}
+	}
+
+	found := false
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		if call, ok := node.(*ast.CallExpr); ok && isQueryCall(call) {
+			if ident, ok := call.Args[0].(*ast.Ident); ok && parameters[ident.Name] {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// stringLiterals returns the string elements, or keys, of a composite literal.
+func stringLiterals(expr ast.Expr) []string {
+	composite, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	var literals []string
+	for _, element := range composite.Elts {
+		if pair, ok := element.(*ast.KeyValueExpr); ok {
+			element = pair.Key
+		}
+		if lit, ok := element.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			literals = append(literals, unquote(lit))
+		}
+	}
+	return literals
+}
+
+func unquote(lit *ast.BasicLit) string {
+	value, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return lit.Value
+	}
+	return value
+}
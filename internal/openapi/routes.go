@@ -0,0 +1,483 @@
+package openapi
+
+import (
+	"aniverse/internal/cache"
+	"aniverse/internal/mapping"
+	"aniverse/internal/types"
+)
+
+// apiRoutes documents the JSON API routes, which the controller mounts under
+// apiPrefix and unversioned.
+func apiRoutes(c components) []route {
+	animePage := c.ref(types.AnimePage{})
+	animeInfo := c.ref(types.AnimeInfo{})
+
+	return []route{
+		{"GET", "/search", Operation{
+			Summary:     "Search anime",
+			Description: "Lists anime matching 'q' and the filters; at least one is required. A numeric 'q' is looked up as an AniList ID and returns that anime instead of a page.",
+			Tags:        []string{"anime"},
+			Parameters:  append([]Parameter{query("q", "Title, or an AniList ID.")}, filterParams()...),
+			Responses:   jsonResponse("A page of anime, or one anime for a numeric 'q'.", &Schema{OneOf: []*Schema{animePage, animeInfo}}),
+		}},
+		{"GET", "/info", Operation{
+			Summary:    "Get an anime with its episodes",
+			Tags:       []string{"anime"},
+			Parameters: []Parameter{required(query("id", "AniList ID."))},
+			Responses:  jsonResponse("The anime, with the episodes of the first provider that carries it.", animeInfo),
+		}},
+		{"POST", "/info/batch", Operation{
+			Summary:     "Get many anime at once",
+			Description: "Results are in the order of 'ids'; an ID that fails carries its own error and leaves the rest intact.",
+			Tags:        []string{"anime"},
+			RequestBody: jsonBody(object(map[string]*Schema{
+				"ids": {Type: "array", Description: "Up to 100 AniList IDs, as numbers or strings.", Items: &Schema{}},
+			}, "ids")),
+			Responses: jsonResponse("One result per ID.", object(map[string]*Schema{
+				"results": {Type: "array", Items: object(map[string]*Schema{
+					"id":    {Type: "string"},
+					"media": animeInfo,
+					"error": {Ref: "#/components/schemas/ErrorDetail"},
+				}, "id")},
+			}, "results")),
+		}},
+		{"GET", "/info/:id/characters", Operation{
+			Summary: "List the characters of an anime",
+			Tags:    []string{"people"},
+			Parameters: append([]Parameter{
+				pathParam("id", "AniList ID."),
+				query("language", "Keep the voice actors of one language, e.g. JAPANESE."),
+			}, pageParams()...),
+			Responses: jsonResponse("A page of characters with their voice actors.", c.ref(types.CharacterPage{})),
+		}},
+		{"GET", "/info/:id/staff", Operation{
+			Summary:    "List the staff of an anime",
+			Tags:       []string{"people"},
+			Parameters: append([]Parameter{pathParam("id", "AniList ID.")}, pageParams()...),
+			Responses:  jsonResponse("A page of staff with their roles.", c.ref(types.StaffPage{})),
+		}},
+		{"GET", "/character/:id", Operation{
+			Summary:    "Describe a character",
+			Tags:       []string{"people"},
+			Parameters: append([]Parameter{pathParam("id", "AniList character ID.")}, pageParams()...),
+			Responses:  jsonResponse("The character with a page of the works they appear in.", c.ref(types.CharacterDetails{})),
+		}},
+		{"GET", "/staff/:id", Operation{
+			Summary:    "Describe a staff member",
+			Tags:       []string{"people"},
+			Parameters: append([]Parameter{pathParam("id", "AniList staff ID.")}, pageParams()...),
+			Responses:  jsonResponse("The staff member with a page of their works and characters.", c.ref(types.StaffDetails{})),
+		}},
+		{"GET", "/trending", Operation{
+			Summary:    "List trending anime",
+			Tags:       []string{"discovery"},
+			Parameters: pageParams(),
+			Responses:  jsonResponse("A page of anime.", animePage),
+		}},
+		{"GET", "/popular", Operation{
+			Summary:    "List popular anime",
+			Tags:       []string{"discovery"},
+			Parameters: pageParams(),
+			Responses:  jsonResponse("A page of anime.", animePage),
+		}},
+		{"GET", "/seasonal", Operation{
+			Summary: "List the anime of a season",
+			Tags:    []string{"discovery"},
+			Parameters: append([]Parameter{
+				enumQuery("season", "Defaults to the current season.", "WINTER", "SPRING", "SUMMER", "FALL"),
+				intQuery("year", "Defaults to the current year."),
+			}, pageParams()...),
+			Responses: jsonResponse("A page of anime.", animePage),
+		}},
+		{"GET", "/upcoming", Operation{
+			Summary:    "List upcoming anime",
+			Tags:       []string{"discovery"},
+			Parameters: pageParams(),
+			Responses:  jsonResponse("A page of anime.", animePage),
+		}},
+		{"GET", "/schedule", Operation{
+			Summary: "Get the airing schedule",
+			Tags:    []string{"discovery"},
+			Parameters: []Parameter{
+				query("from", "First day, e.g. 2026-10-17. Defaults to today."),
+				query("to", "Last day, at most 14 days after 'from'. Defaults to 'from' plus 6 days."),
+				query("tz", "IANA timezone the days are counted in. Defaults to UTC."),
+			},
+			Responses: jsonResponse("What airs each day.", object(map[string]*Schema{
+				"timezone":  {Type: "string"},
+				"days":      {Type: "array", Items: c.ref(types.ScheduleDay{})},
+				"truncated": {Type: "boolean", Description: "More broadcasts air in the range than were returned; narrow it to see them all."},
+			}, "timezone", "days", "truncated")),
+		}},
+		{"GET", "/manga/search", Operation{
+			Summary:    "Search manga",
+			Tags:       []string{"manga"},
+			Parameters: append([]Parameter{query("q", "Title to search for.")}, filterParams()...),
+			Responses:  jsonResponse("A page of manga.", c.ref(types.MangaPage{})),
+		}},
+		{"GET", "/manga/info", Operation{
+			Summary:    "Get a manga with its chapters",
+			Tags:       []string{"manga"},
+			Parameters: []Parameter{required(query("id", "AniList ID."))},
+			Responses:  jsonResponse("The manga, with the chapters of the first provider that carries it.", c.ref(types.MangaInfo{})),
+		}},
+		{"GET", "/me/list", Operation{
+			Summary: "Get the signed-in user's lists",
+			Tags:    []string{"tracking"},
+			Parameters: []Parameter{
+				enumQuery("status", "Keep a single list.", listStatuses()...),
+				query("provider", "Tracker to read from. Defaults to the first one signed in to."),
+			},
+			Responses: jsonResponse("The user and their lists.", object(map[string]*Schema{
+				"provider": {Type: "string"},
+				"user":     c.ref(types.Viewer{}),
+				"lists":    {Type: "array", Items: c.ref(types.MediaList{})},
+			}, "provider", "user", "lists")),
+		}},
+		{"PATCH", "/me/list/:id", Operation{
+			Summary: "Add or change a list entry",
+			Tags:    []string{"tracking"},
+			Parameters: []Parameter{
+				pathParam("id", "AniList ID."),
+				query("provider", "Tracker to update. Defaults to the first one signed in to."),
+			},
+			RequestBody: jsonBody(object(map[string]*Schema{
+				"status":   {Type: "string", Enum: listStatuses()},
+				"progress": {Type: "integer", Description: "Watched episodes."},
+				"score":    {Type: "number", Description: "0 to 10."},
+			})),
+			Responses: jsonResponse("The updated entry.", c.ref(types.ListEntry{})),
+		}},
+		{"POST", "/me/progress", Operation{
+			Summary: "Mark an episode watched",
+			Tags:    []string{"tracking"},
+			Parameters: []Parameter{
+				required(query("id", "AniList ID.")),
+				required(intQuery("ep", "Episode number.")),
+			},
+			Responses: jsonResponse("The trackers the progress was saved to.", object(map[string]*Schema{
+				"providers": {Type: "array", Items: &Schema{Type: "string"}},
+			}, "providers")),
+		}},
+		{"GET", "/providers", Operation{
+			Summary: "List the providers",
+			Tags:    []string{"meta"},
+			Responses: jsonResponse("Every provider in order of preference.", &Schema{Type: "array", Items: object(map[string]*Schema{
+				"id":                 {Type: "string"},
+				"url":                {Type: "string"},
+				"formats":            {Type: "array", Items: &Schema{Type: "string"}},
+				"needsProxy":         {Type: "boolean"},
+				"useGoogleTranslate": {Type: "boolean"},
+				"roles":              {Type: "array", Items: &Schema{Type: "string"}},
+			}, "id", "url", "formats", "needsProxy", "useGoogleTranslate", "roles")}),
+		}},
+		{"GET", "/cache/stats", Operation{
+			Summary:   "Get the cache counters",
+			Tags:      []string{"meta"},
+			Responses: jsonResponse("Hits, misses and evictions so far.", c.ref(cache.Stats{})),
+		}},
+	}
+}
+
+// routes documents the other routes registered by the controller.
+func routes(c components) []route {
+	return []route{
+		{"GET", "/v1/episodes", Operation{
+			Summary:     "List the episodes of an anime",
+			Description: "The episodes /info embeds, a page at a time, with titles from MyAnimeList where it has them.",
+			Tags:        []string{"anime"},
+			Parameters: append([]Parameter{
+				required(query("id", "AniList ID.")),
+				enumQuery("sort", "Order by episode number. Defaults to asc.", "asc", "desc"),
+				boolQuery("dub", "Only the episodes with a dub."),
+			}, pageParams()...),
+			Responses: jsonResponse("A page of episodes.", c.ref(types.EpisodePage{})),
+		}},
+		{"GET", "/v1/sources", Operation{
+			Summary:     "Get the streams of an episode",
+			Description: "What /watch plays, as JSON.",
+			Tags:        []string{"streaming"},
+			Parameters:  sourceParams(),
+			Responses:   jsonResponse("The episode with its streams in 'source'.", c.ref(types.Episode{})),
+		}},
+		{"GET", "/v1/servers", Operation{
+			Summary:     "List the servers of an episode",
+			Description: "Pass a server's name as 'server' to /v1/sources or /watch to stream from it, e.g. when another is down.",
+			Tags:        []string{"streaming"},
+			Parameters: []Parameter{
+				required(query("id", "AniList ID.")),
+				required(intQuery("ep", "Episode number.")),
+				boolQuery("dub", "List the servers of the dubbed version."),
+			},
+			Responses: jsonResponse("The servers, in the order the provider offers them.", c.ref([]types.EpisodeServer{})),
+		}},
+		{"GET", "/watch", Operation{
+			Summary:    "Watch an episode",
+			Tags:       []string{"streaming"},
+			Parameters: sourceParams(),
+			Responses:  htmlResponse("The player page."),
+		}},
+		{"GET", "/manga/read", Operation{
+			Summary: "Read a chapter",
+			Tags:    []string{"manga"},
+			Parameters: []Parameter{
+				required(query("id", "AniList ID.")),
+				required(query("chapter", "Chapter ID from /manga/info.")),
+			},
+			Responses: htmlResponse("The reader page."),
+		}},
+		{"GET", "/auth/:tracker/login", Operation{
+			Summary:    "Sign in to a tracker",
+			Tags:       []string{"tracking"},
+			Parameters: []Parameter{trackerParam()},
+			Responses: map[string]Response{
+				"302":     {Description: "Redirect to the tracker's sign-in page."},
+				"default": errorResponse,
+			},
+		}},
+		{"GET", "/auth/:tracker/callback", Operation{
+			Summary: "Complete signing in",
+			Tags:    []string{"tracking"},
+			Parameters: []Parameter{
+				trackerParam(),
+				query("code", "Authorization code."),
+				query("state", "State from the login redirect."),
+				query("error", "Set by the tracker when the user declined."),
+			},
+			Responses: jsonResponse("The signed-in user.", object(map[string]*Schema{
+				"provider": {Type: "string"},
+				"user":     c.ref(types.Viewer{}),
+			}, "provider", "user")),
+		}},
+		{"POST", "/auth/:tracker/logout", Operation{
+			Summary:    "Sign out of a tracker",
+			Tags:       []string{"tracking"},
+			Parameters: []Parameter{trackerParam()},
+			Responses: map[string]Response{
+				"204":     {Description: "Signed out."},
+				"default": errorResponse,
+			},
+		}},
+		{"GET", "/graphql", Operation{
+			Summary: "Run a GraphQL query",
+			Tags:    []string{"graphql"},
+			Parameters: []Parameter{
+				required(query("query", "GraphQL query.")),
+				query("operationName", "Operation to run."),
+				query("variables", "Variables as a JSON object."),
+			},
+			Responses: jsonResponse("The GraphQL response.", graphQLResponse()),
+		}},
+		{"POST", "/graphql", Operation{
+			Summary: "Run a GraphQL query",
+			Tags:    []string{"graphql"},
+			RequestBody: jsonBody(object(map[string]*Schema{
+				"query":         {Type: "string"},
+				"operationName": {Type: "string"},
+				"variables":     {Type: "object"},
+			}, "query")),
+			Responses: jsonResponse("The GraphQL response.", graphQLResponse()),
+		}},
+		{"GET", "/proxy/m3u8", Operation{
+			Summary:     "Proxy an HLS playlist",
+			Description: "Links are generated by /watch and signed; they cannot be built by hand.",
+			Tags:        []string{"streaming"},
+			Parameters:  proxyParams(),
+			Responses: map[string]Response{
+				"200":     {Description: "The playlist, with every URI rewritten through the proxy.", Content: map[string]MediaType{"application/vnd.apple.mpegurl": {Schema: &Schema{Type: "string"}}}},
+				"default": errorResponse,
+			},
+		}},
+		{"GET", "/proxy/segment", Operation{
+			Summary:     "Proxy a media segment",
+			Description: "Links are generated by /proxy/m3u8 and signed. Range requests are forwarded.",
+			Tags:        []string{"streaming"},
+			Parameters:  proxyParams(),
+			Responses: map[string]Response{
+				"200":     {Description: "The segment, streamed.", Content: map[string]MediaType{"application/octet-stream": {Schema: &Schema{Type: "string", Format: "binary"}}}},
+				"206":     {Description: "Part of the segment."},
+				"default": errorResponse,
+			},
+		}},
+		{"GET", "/openapi.json", Operation{
+			Summary:   "Get this document",
+			Tags:      []string{"meta"},
+			Responses: map[string]Response{"200": {Description: "The OpenAPI document."}},
+		}},
+		{"GET", "/docs", Operation{
+			Summary:   "Browse this document",
+			Tags:      []string{"meta"},
+			Responses: htmlResponse("Interactive API docs."),
+		}},
+		{"GET", "/admin/mappings/:anilistId", Operation{
+			Summary:    "Get a stored mapping",
+			Tags:       []string{"admin"},
+			Parameters: mappingParams(),
+			Security:   adminSecurity(),
+			Responses:  jsonResponse("The mapping.", c.ref(mapping.Record{})),
+		}},
+		{"PUT", "/admin/mappings/:anilistId", Operation{
+			Summary:    "Pin a mapping",
+			Tags:       []string{"admin"},
+			Parameters: mappingParams(),
+			Security:   adminSecurity(),
+			RequestBody: jsonBody(object(map[string]*Schema{
+				"sub": {Type: "string", Description: "Provider ID of the subbed version."},
+				"dub": {Type: "string", Description: "Provider ID of the dubbed version."},
+			})),
+			Responses: jsonResponse("The pinned mapping.", c.ref(mapping.Record{})),
+		}},
+		{"DELETE", "/admin/mappings/:anilistId", Operation{
+			Summary:    "Forget a mapping",
+			Tags:       []string{"admin"},
+			Parameters: mappingParams(),
+			Security:   adminSecurity(),
+			Responses: map[string]Response{
+				"204":     {Description: "Deleted; it is recomputed on next use."},
+				"default": errorResponse,
+			},
+		}},
+	}
+}
+
+// filterParams are the search filters read by the controller's searchFilter.
+func filterParams() []Parameter {
+	return append([]Parameter{
+		query("genres", "Comma-separated genres that must all match."),
+		query("exclude_genres", "Comma-separated genres to leave out."),
+		query("tags", "Comma-separated tags that must all match."),
+		query("exclude_tags", "Comma-separated tags to leave out."),
+		query("format", "Comma-separated formats, e.g. TV,MOVIE."),
+		query("sort", "Comma-separated sort orders, e.g. SCORE_DESC."),
+		intQuery("year", "Release year."),
+		enumQuery("season", "Release season.", "WINTER", "SPRING", "SUMMER", "FALL"),
+		enumQuery("status", "Release status.", "FINISHED", "RELEASING", "NOT_YET_RELEASED", "CANCELLED"),
+		query("country", "Country of origin, e.g. JP."),
+		{Name: "min_score", In: "query", Description: "Minimum score from 0 to 10.", Schema: &Schema{Type: "number"}},
+		intQuery("min_episodes", "Minimum number of episodes."),
+		intQuery("max_episodes", "Maximum number of episodes."),
+	}, pageParams()...)
+}
+
+func sourceParams() []Parameter {
+	return []Parameter{
+		required(query("id", "AniList ID.")),
+		required(intQuery("ep", "Episode number.")),
+		boolQuery("dub", "Play the dubbed version."),
+		query("server", "Server to stream from, as listed by /v1/servers. Defaults to the provider's own player."),
+		boolQuery("proxy", "Route the streams through the HLS proxy. Defaults to what the provider needs."),
+	}
+}
+
+func pageParams() []Parameter {
+	return []Parameter{
+		intQuery("page", "Page number, from 1."),
+		intQuery("per_page", "Results per page, at most 50."),
+		intQuery("perPage", "Alias of per_page."),
+	}
+}
+
+func proxyParams() []Parameter {
+	return []Parameter{
+		required(query("url", "Upstream URL.")),
+		query("h", "Upstream headers."),
+		required(intQuery("exp", "Unix time the link expires at.")),
+		required(query("sig", "Signature of the link.")),
+	}
+}
+
+func mappingParams() []Parameter {
+	return []Parameter{
+		pathParam("anilistId", "AniList ID."),
+		query("provider", "Episode or chapter provider, e.g. mangadex. Defaults to the preferred episode provider."),
+	}
+}
+
+func trackerParam() Parameter {
+	return pathParam("tracker", "Tracker ID, e.g. anilist or mal.")
+}
+
+func listStatuses() []string {
+	statuses := make([]string, len(types.ListStatuses))
+	for i, status := range types.ListStatuses {
+		statuses[i] = string(status)
+	}
+	return statuses
+}
+
+func adminSecurity() []map[string][]string {
+	return []map[string][]string{{"adminToken": {}}}
+}
+
+func query(name string, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+func intQuery(name string, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "integer"}}
+}
+
+func boolQuery(name string, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "boolean"}}
+}
+
+func enumQuery(name string, description string, values ...string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string", Enum: values}}
+}
+
+func pathParam(name string, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+func required(parameter Parameter) Parameter {
+	parameter.Required = true
+	return parameter
+}
+
+func object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+func graphQLResponse() *Schema {
+	return object(map[string]*Schema{
+		"data":   {Type: "object", Nullable: true},
+		"errors": {Type: "array", Items: &Schema{Type: "object"}},
+	})
+}
+
+// errorResponse is the error envelope of the controller's ErrorHandler.
+var errorResponse = Response{
+	Description: "The error, e.g. {\"error\": {\"code\": \"NOT_FOUND\", \"message\": \"...\"}}.",
+	Content:     map[string]MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}}},
+}
+
+func (c components) addErrorSchemas() {
+	c["ErrorDetail"] = object(map[string]*Schema{
+		"code":      {Type: "string", Description: "e.g. NOT_FOUND, MAPPING_NOT_FOUND, UPSTREAM_UNAVAILABLE or RATE_LIMITED."},
+		"message":   {Type: "string"},
+		"provider":  {Type: "string", Description: "The upstream provider the error came from, if any."},
+		"requestId": {Type: "string", Description: "Also sent as X-Request-ID."},
+	}, "code", "message")
+	c["Error"] = object(map[string]*Schema{
+		"error": {Ref: "#/components/schemas/ErrorDetail"},
+	}, "error")
+}
+
+// jsonResponse documents a 200 with schema and the error envelope otherwise.
+func jsonResponse(description string, schema *Schema) map[string]Response {
+	return map[string]Response{
+		"200":     {Description: description, Content: map[string]MediaType{"application/json": {Schema: schema}}},
+		"default": errorResponse,
+	}
+}
+
+func htmlResponse(description string) map[string]Response {
+	return map[string]Response{
+		"200":     {Description: description, Content: map[string]MediaType{"text/html": {Schema: &Schema{Type: "string"}}}},
+		"default": errorResponse,
+	}
+}
@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"aniverse/internal/types"
+)
+
+// Schema is an OpenAPI 3.0 schema object, limited to what the API uses.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// components collects the schemas of named types under #/components/schemas
+// as they are first referenced.
+type components map[string]*Schema
+
+// ref returns a reference to the schema of the Go type of v, adding it (and
+// every type it refers to) to the components.
+func (c components) ref(v interface{}) *Schema {
+	return c.schemaOf(reflect.TypeOf(v))
+}
+
+func (c components) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(types.AiringEpisode{}):
+		// MarshalJSON adds the countdown, which the struct does not hold.
+		_, known := c[t.Name()]
+		ref := c.structRef(t)
+		if !known {
+			c[t.Name()].Properties["timeUntilAiring"] = &Schema{Type: "integer", Description: "Seconds until the episode airs, 0 once it has."}
+			c[t.Name()].Required = append(c[t.Name()].Required, "timeUntilAiring")
+		}
+		return ref
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := c.schemaOf(t.Elem())
+		if schema.Ref != "" {
+			// Siblings of $ref are ignored, so nullable needs a wrapper.
+			return &Schema{OneOf: []*Schema{schema}, Nullable: true}
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: c.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: c.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return c.structRef(t)
+	}
+	return &Schema{}
+}
+
+// structRef adds the object schema of the struct type t to the components
+// and returns a reference to it.
+func (c components) structRef(t reflect.Type) *Schema {
+	ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+	if _, ok := c[t.Name()]; ok {
+		return ref
+	}
+
+	// Registered before the fields so self-references terminate.
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	c[t.Name()] = schema
+	c.addFields(schema, t)
+	return ref
+}
+
+// addFields adds the JSON fields of the struct type t to schema. Fields that
+// are neither pointers nor omitempty are always present, so they are required.
+func (c components) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			c.addFields(schema, field.Type)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = c.schemaOf(field.Type)
+		if field.Type.Kind() != reflect.Ptr && !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -1,24 +1,47 @@
 package anilist
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strings"
 
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
+	"aniverse/internal/httpclient"
+	"aniverse/internal/provider"
 	"aniverse/internal/types"
 )
 
+var _ provider.MetaProvider = (*AniListBase)(nil)
+
 type AniListBase struct {
 	BaseURL string
+	siteURL string
 	query   string
+	cache   cache.Store
+	client  *httpclient.Client
+
+	// OAuth client credentials; login is disabled without a client ID.
+	clientID     string
+	clientSecret string
+	redirectURL  string
 }
 
-func NewAniListBase() *AniListBase {
+func NewAniListBase(cfg config.AniListConfig, store cache.Store, client *httpclient.Client) *AniListBase {
 	return &AniListBase{
-		BaseURL: "https://graphql.anilist.co",
+		BaseURL: cfg.APIURL,
+		siteURL: cfg.SiteURL,
+		cache:   store,
+		client:  client,
+
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+
 		query: `
 id
 idMal
@@ -40,6 +63,16 @@ format
 status(version: 2)
 episodes
 duration
+nextAiringEpisode {
+  episode
+  airingAt
+}
+airingSchedule(notYetAired: true, perPage: 25) {
+  nodes {
+    episode
+    airingAt
+  }
+}
 genres
 synonyms
 isAdult
@@ -49,9 +82,11 @@ countryOfOrigin
 tags {
   name
 }
-characters {
+characters(sort: [ROLE, RELEVANCE, ID]) {
   edges {
+    role
     node {
+      id
       name {
         full
       }
@@ -59,13 +94,15 @@ characters {
         large
       }
     }
-    voiceActors {
+    voiceActors(sort: [RELEVANCE, ID]) {
+      id
       name {
         full
       }
       image {
         large
       }
+      languageV2
     }
   }
 }
@@ -93,7 +130,7 @@ func (a *AniListBase) ID() string {
 }
 
 func (a *AniListBase) URL() string {
-	return "https://anilist.co"
+	return a.siteURL
 }
 
 func (a *AniListBase) Formats() []types.Format {
@@ -115,81 +152,151 @@ func (a *AniListBase) UseGoogleTranslate() bool {
 	return false
 }
 
-func (anilist *AniListBase) Search(query string, mediaType types.MediaType, formats []types.Format, page int, perPage int) ([]types.AnimeInfo, error) {
-	graphqlQuery := `
-query ($page: Int, $perPage: Int, $search: String, $type: MediaType, $format: [MediaFormat]) {
+// Search lists the media matching filter. Type and Formats default to anime
+// in Formats(); adult entries are always excluded.
+func (a *AniListBase) Search(ctx context.Context, filter types.MediaFilter) (*types.AnimePage, error) {
+	filter = normalizeFilter(filter, types.TypeAnime, a.Formats())
+
+	filterKey, _ := json.Marshal(filter)
+	cacheKey := cache.Key(a.ID(), "search", strings.ToLower(string(filterKey)))
+	var cached types.AnimePage
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	response, err := a.page(ctx, pageQuery(a.query), filterVariables(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	cache.SetJSON(ctx, a.cache, cacheKey, response, cache.TTLSearch)
+	return response, nil
+}
+
+// normalizeFilter fills in the defaults of a Page.media listing.
+func normalizeFilter(filter types.MediaFilter, mediaType types.MediaType, formats []types.Format) types.MediaFilter {
+	if filter.Type == "" {
+		filter.Type = mediaType
+	}
+	if len(filter.Formats) == 0 {
+		filter.Formats = formats
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 {
+		filter.PerPage = 10
+	}
+	filter.Search = strings.TrimSpace(filter.Search)
+	return filter
+}
+
+// pageQuery returns the filtered Page.media query selecting fields.
+func pageQuery(fields string) string {
+	return `
+query ($page: Int, $perPage: Int, $search: String, $type: MediaType, $format: [MediaFormat], $genres: [String], $excludedGenres: [String], $tags: [String], $excludedTags: [String], $seasonYear: Int, $season: MediaSeason, $status: MediaStatus, $country: CountryCode, $minScore: Int, $minEpisodes: Int, $maxEpisodes: Int, $sort: [MediaSort]) {
   Page(page: $page, perPage: $perPage) {
-    media(type: $type, format_in: $format, search: $search) {
-` + anilist.query + `
+    pageInfo {
+      total
+      perPage
+      currentPage
+      lastPage
+      hasNextPage
+    }
+    media(search: $search, type: $type, format_in: $format, genre_in: $genres, genre_not_in: $excludedGenres, tag_in: $tags, tag_not_in: $excludedTags, seasonYear: $seasonYear, season: $season, status: $status, countryOfOrigin: $country, averageScore_greater: $minScore, episodes_greater: $minEpisodes, episodes_lesser: $maxEpisodes, sort: $sort, isAdult: false) {
+` + fields + `
     }
   }
 }
 `
+}
 
+// filterVariables translates filter into the variables of Search's query.
+// AniList ignores null arguments, so only set fields are sent.
+func filterVariables(filter types.MediaFilter) map[string]interface{} {
 	variables := map[string]interface{}{
-		"search":  query,
-		"type":    mediaType,
-		"format":  formats,
-		"page":    page,
-		"perPage": perPage,
+		"type":    filter.Type,
+		"format":  filter.Formats,
+		"page":    filter.Page,
+		"perPage": filter.PerPage,
 	}
-
-	payload := map[string]interface{}{
-		"query":     graphqlQuery,
-		"variables": variables,
+	if filter.Search != "" {
+		variables["search"] = filter.Search
 	}
-
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
+	if len(filter.Genres) > 0 {
+		variables["genres"] = filter.Genres
 	}
-
-	req, err := http.NewRequest("POST", anilist.BaseURL, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, err
+	if len(filter.ExcludedGenres) > 0 {
+		variables["excludedGenres"] = filter.ExcludedGenres
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Origin", "https://anilist.co")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if len(filter.Tags) > 0 {
+		variables["tags"] = filter.Tags
 	}
-	defer resp.Body.Close()
+	if len(filter.ExcludedTags) > 0 {
+		variables["excludedTags"] = filter.ExcludedTags
+	}
+	if filter.Year != 0 {
+		variables["seasonYear"] = filter.Year
+	}
+	if filter.Season != "" {
+		variables["season"] = filter.Season
+	}
+	if filter.Status != "" {
+		variables["status"] = filter.Status
+	}
+	if filter.CountryOfOrigin != "" {
+		variables["country"] = strings.ToUpper(filter.CountryOfOrigin)
+	}
+	// The _greater and _lesser arguments are exclusive.
+	if filter.MinScore > 0 {
+		// Round, as 8.2*10 is 81.999... in floating point.
+		variables["minScore"] = int(math.Round(filter.MinScore*10)) - 1
+	}
+	if filter.MinEpisodes > 0 {
+		variables["minEpisodes"] = filter.MinEpisodes - 1
+	}
+	if filter.MaxEpisodes > 0 {
+		variables["maxEpisodes"] = filter.MaxEpisodes + 1
+	}
+	if len(filter.Sort) > 0 {
+		variables["sort"] = filter.Sort
+	}
+	return variables
+}
 
+// page runs a Page.media query and maps its non-adult results.
+func (a *AniListBase) page(ctx context.Context, graphqlQuery string, variables map[string]interface{}) (*types.AnimePage, error) {
 	var response struct {
-		Data struct {
-			Page struct {
-				Media []types.Media `json:"media"`
-			} `json:"Page"`
-		} `json:"data"`
+		Page struct {
+			PageInfo types.PageInfo `json:"pageInfo"`
+			Media    []types.Media  `json:"media"`
+		} `json:"Page"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
 		return nil, err
 	}
 
-	mediaList := response.Data.Page.Media
-	if mediaList == nil {
-		return nil, errors.New("no media found")
+	result := &types.AnimePage{
+		PageInfo: response.Page.PageInfo,
+		Results:  []types.AnimeInfo{},
 	}
-
-	var results []types.AnimeInfo
-	for _, media := range mediaList {
+	for _, media := range response.Page.Media {
 		if media.IsAdult {
 			continue
 		}
-		animeInfo := anilist.mapMediaToAnimeInfo(media)
-		results = append(results, animeInfo)
+		result.Results = append(result.Results, a.mapMediaToAnimeInfo(media))
 	}
-
-	return results, nil
+	return result, nil
 }
 
-func (a *AniListBase) GetMedia(id string) (*types.AnimeInfo, error) {
+func (a *AniListBase) GetMedia(ctx context.Context, id string) (*types.AnimeInfo, error) {
+	cacheKey := cache.Key(a.ID(), "media", id)
+	var cached types.AnimeInfo
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
 	graphqlQuery := `
 query ($id: Int) {
   Media(id: $id) {
@@ -202,49 +309,83 @@ query ($id: Int) {
 		"id": id,
 	}
 
-	payload := map[string]interface{}{
-		"query":     graphqlQuery,
-		"variables": variables,
+	var response struct {
+		Media types.Media `json:"Media"`
 	}
 
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", a.BaseURL, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return nil, err
+	media := response.Media
+	if media.IsAdult {
+		return nil, apperror.Forbidden(a.ID(), "media is adult content")
+	}
+
+	animeInfo := a.mapMediaToAnimeInfo(media)
+	cache.SetJSON(ctx, a.cache, cacheKey, animeInfo, cache.TTLMedia)
+	return &animeInfo, nil
+}
+
+// graphql POSTs a query to the AniList API and decodes its "data" field into out.
+func (a *AniListBase) graphql(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	return a.graphqlAs(ctx, "", query, variables, out)
+}
+
+// graphqlAs is graphql on behalf of the user the access token belongs to.
+func (a *AniListBase) graphqlAs(ctx context.Context, accessToken string, query string, variables map[string]interface{}, out interface{}) error {
+	payload := map[string]interface{}{
+		"query":     query,
+		"variables": variables,
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Origin", "https://anilist.co")
+	headers := map[string]string{"Origin": a.siteURL}
+	if accessToken != "" {
+		headers["Authorization"] = "Bearer " + accessToken
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Queries are safe to retry; mutations must be sent once.
+	post := a.client.QueryJSON
+	if strings.HasPrefix(strings.TrimSpace(query), "mutation") {
+		post = a.client.PostJSON
+	}
+	resp, err := post(ctx, a.BaseURL, headers, payload)
 	if err != nil {
-		return nil, err
+		return apperror.Upstream(a.ID(), err)
 	}
 	defer resp.Body.Close()
 
 	var response struct {
-		Data struct {
-			Media types.Media `json:"Media"`
-		} `json:"data"`
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+			Status  int    `json:"status"`
+		} `json:"errors"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, err
+		return apperror.UpstreamUnavailable(a.ID(), fmt.Errorf("failed to decode AniList response (status %d): %w", resp.StatusCode, err))
 	}
 
-	media := response.Data.Media
-	if media.IsAdult {
-		return nil, errors.New("media is adult content")
+	if len(response.Errors) > 0 {
+		status := resp.StatusCode
+		messages := make([]string, 0, len(response.Errors))
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+			if e.Status != 0 {
+				status = e.Status
+			}
+		}
+		classified := apperror.FromStatus(a.ID(), status)
+		classified.Err = fmt.Errorf("AniList error (status %d): %s", status, strings.Join(messages, "; "))
+		return classified
 	}
 
-	animeInfo := a.mapMediaToAnimeInfo(media)
-	return &animeInfo, nil
+	if resp.StatusCode != http.StatusOK {
+		return apperror.FromStatus(a.ID(), resp.StatusCode)
+	}
+
+	return json.Unmarshal(response.Data, out)
 }
 
 func (a *AniListBase) mapMediaToAnimeInfo(media types.Media) types.AnimeInfo {
@@ -282,6 +423,23 @@ func (a *AniListBase) mapMediaToAnimeInfo(media types.Media) types.AnimeInfo {
 	description := stripHTMLTags(media.Description)
 
 	color := media.CoverImage.Color
+
+	// Episodes is the planned total; while airing, the current episode is
+	// the one before the next broadcast.
+	currentEpisode := media.Episodes
+	var nextAiring *types.AiringEpisode
+	if media.NextAiringEpisode != nil {
+		currentEpisode = media.NextAiringEpisode.Episode - 1
+		nextAiring = &types.AiringEpisode{
+			Episode:  media.NextAiringEpisode.Episode,
+			AiringAt: media.NextAiringEpisode.AiringAt,
+		}
+	}
+
+	var airingSchedule []types.AiringEpisode
+	for _, node := range media.AiringSchedule.Nodes {
+		airingSchedule = append(airingSchedule, types.AiringEpisode{Episode: node.Episode, AiringAt: node.AiringAt})
+	}
 	animeInfo := types.AnimeInfo{
 		ID:              fmt.Sprintf("%d", media.ID),
 		IDMal:           fmt.Sprintf("%d", media.IDMal),
@@ -304,9 +462,12 @@ func (a *AniListBase) mapMediaToAnimeInfo(media types.Media) types.AnimeInfo {
 		Artwork:         artworks,
 		Characters:      characters,
 		Relations:       relations,
-		CurrentEpisode:  media.Episodes,
+		CurrentEpisode:  currentEpisode,
 		Duration:        &media.Duration,
 		Color:           &color,
+
+		NextAiringEpisode: nextAiring,
+		AiringSchedule:    airingSchedule,
 	}
 
 	return animeInfo
@@ -316,15 +477,22 @@ func (a *AniListBase) mapCharacters(characters types.Characters) []types.Charact
 	var result []types.Character
 	for _, edge := range characters.Edges {
 		character := types.Character{
-			Name:  edge.Node.Name.Full,
-			Image: edge.Node.Image.Large,
+			ID:          fmt.Sprintf("%d", edge.Node.ID),
+			Name:        edge.Node.Name.Full,
+			Image:       edge.Node.Image.Large,
+			Role:        edge.Role,
+			VoiceActors: []types.VoiceActor{},
 		}
-		if len(edge.VoiceActors) > 0 {
-			va := edge.VoiceActors[0]
-			character.VoiceActor = &types.VoiceActor{
-				Name:  va.Name.Full,
-				Image: va.Image.Large,
-			}
+		for _, va := range edge.VoiceActors {
+			character.VoiceActors = append(character.VoiceActors, types.VoiceActor{
+				ID:       fmt.Sprintf("%d", va.ID),
+				Name:     va.Name.Full,
+				Image:    va.Image.Large,
+				Language: va.LanguageV2,
+			})
+		}
+		if len(character.VoiceActors) > 0 {
+			character.VoiceActor = &character.VoiceActors[0]
 		}
 		result = append(result, character)
 	}
@@ -0,0 +1,29 @@
+package anilist
+
+import (
+	"testing"
+
+	"aniverse/internal/types"
+)
+
+func TestFilterVariablesMinScore(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  interface{}
+	}{
+		{0, nil},
+		{0.5, 4},
+		{7, 69},
+		{7.3, 72},
+		{8.2, 81},
+		{8.25, 82},
+		{9.9, 98},
+		{10, 99},
+	}
+	for _, tt := range tests {
+		variables := filterVariables(types.MediaFilter{MinScore: tt.score})
+		if got := variables["minScore"]; got != tt.want {
+			t.Errorf("MinScore %v: minScore = %v, want %v", tt.score, got, tt.want)
+		}
+	}
+}
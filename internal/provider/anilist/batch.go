@@ -0,0 +1,126 @@
+package anilist
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+var _ provider.BatchMetaProvider = (*AniListBase)(nil)
+
+// batchSize is how many IDs GetMediaBatch asks for per query. Page.media
+// returns up to 50, but the full selection of 50 media exceeds AniList's
+// query complexity limit.
+const batchSize = 25
+
+// GetMediaBatch returns the anime with the given AniList IDs, in order. Cached
+// entries are reused and the rest are fetched with Page.media(id_in), one
+// chunk at a time; a failed chunk only fails its own IDs.
+func (a *AniListBase) GetMediaBatch(ctx context.Context, ids []string) []types.MediaResult {
+	results := make([]types.MediaResult, len(ids))
+	found := make(map[int]*types.AnimeInfo)
+	failed := make(map[int]error)
+
+	var missing []int
+	for i, id := range ids {
+		results[i].ID = id
+
+		numericID, err := strconv.Atoi(id)
+		if err != nil || numericID < 1 {
+			results[i].Err = apperror.BadRequest(fmt.Sprintf("Invalid AniList ID: %s", id))
+			continue
+		}
+		if _, ok := found[numericID]; ok || failed[numericID] != nil {
+			continue
+		}
+
+		var cached types.AnimeInfo
+		if cache.GetJSON(ctx, a.cache, cache.Key(a.ID(), "media", strconv.Itoa(numericID)), &cached) {
+			found[numericID] = &cached
+			continue
+		}
+		// Until it is fetched the ID counts as not found, which also keeps a
+		// duplicate from being fetched twice.
+		failed[numericID] = apperror.NotFound(a.ID(), "media %d not found", numericID)
+		missing = append(missing, numericID)
+	}
+
+	for start := 0; start < len(missing); start += batchSize {
+		end := start + batchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		chunk := missing[start:end]
+
+		media, err := a.mediaByIDs(ctx, chunk)
+		if err != nil {
+			for _, numericID := range chunk {
+				failed[numericID] = err
+			}
+			continue
+		}
+
+		for _, numericID := range chunk {
+			m, ok := media[numericID]
+			if !ok {
+				continue
+			}
+			if m.IsAdult {
+				failed[numericID] = apperror.Forbidden(a.ID(), "media is adult content")
+				continue
+			}
+			animeInfo := a.mapMediaToAnimeInfo(m)
+			cache.SetJSON(ctx, a.cache, cache.Key(a.ID(), "media", strconv.Itoa(numericID)), animeInfo, cache.TTLMedia)
+			found[numericID] = &animeInfo
+			delete(failed, numericID)
+		}
+	}
+
+	for i := range results {
+		if results[i].Err != nil {
+			continue
+		}
+		numericID, _ := strconv.Atoi(results[i].ID)
+		results[i].Media, results[i].Err = found[numericID], failed[numericID]
+	}
+	return results
+}
+
+// mediaByIDs fetches up to 50 anime by ID, keyed by ID.
+func (a *AniListBase) mediaByIDs(ctx context.Context, ids []int) (map[int]types.Media, error) {
+	graphqlQuery := `
+query ($ids: [Int], $perPage: Int) {
+  Page(page: 1, perPage: $perPage) {
+    media(id_in: $ids, type: ANIME) {
+` + a.query + `
+    }
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"ids":     ids,
+		"perPage": len(ids),
+	}
+
+	var response struct {
+		Page struct {
+			Media []types.Media `json:"media"`
+		} `json:"Page"`
+	}
+
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
+		return nil, err
+	}
+
+	media := make(map[int]types.Media, len(response.Page.Media))
+	for _, m := range response.Page.Media {
+		media[m.ID] = m
+	}
+	return media, nil
+}
@@ -0,0 +1,141 @@
+package anilist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
+	"aniverse/internal/httpclient"
+	"aniverse/internal/types"
+)
+
+// fakeAniList answers Page.media(id_in) queries. IDs in missing are left
+// out of the response, adult ones are flagged isAdult, and a chunk holding
+// failID fails with a GraphQL error.
+type fakeAniList struct {
+	missing map[int]bool
+	adult   map[int]bool
+	failID  int
+
+	mu       sync.Mutex
+	requests [][]int
+}
+
+func (f *fakeAniList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Variables struct {
+			IDs []int `json:"ids"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids := request.Variables.IDs
+
+	f.mu.Lock()
+	f.requests = append(f.requests, ids)
+	f.mu.Unlock()
+
+	media := []types.Media{}
+	for _, id := range ids {
+		if id == f.failID {
+			w.Write([]byte(`{"data": null, "errors": [{"message": "Internal Server Error", "status": 500}]}`))
+			return
+		}
+		if !f.missing[id] {
+			media = append(media, types.Media{ID: id, IsAdult: f.adult[id], Format: "TV", Title: types.Title{Romaji: fmt.Sprint("Anime ", id)}})
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"Page": map[string]interface{}{"media": media}}})
+}
+
+// kindOf returns the apperror kind of err, or "" for nil.
+func kindOf(err error) apperror.Kind {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	if err != nil {
+		return "unclassified"
+	}
+	return ""
+}
+
+func TestGetMediaBatch(t *testing.T) {
+	upstream := &fakeAniList{
+		missing: map[int]bool{7: true},
+		adult:   map[int]bool{5: true},
+		failID:  27,
+	}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	store := cache.New("memory", "", 100)
+	a := NewAniListBase(config.AniListConfig{APIURL: server.URL}, store, httpclient.New(httpclient.DefaultConfig()))
+
+	// 31 is cached, so only 1-30 are fetched: a full chunk of batchSize and
+	// a second, failing one.
+	cache.SetJSON(context.Background(), store, cache.Key(a.ID(), "media", "31"), types.AnimeInfo{ID: "31"}, cache.TTLMedia)
+	ids := []string{"31", "abc"}
+	for id := 1; id <= 30; id++ {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	ids = append(ids, "3", "0", "7")
+
+	results := a.GetMediaBatch(context.Background(), ids)
+
+	if len(results) != len(ids) {
+		t.Fatalf("got %d results, want %d", len(results), len(ids))
+	}
+	for i, result := range results {
+		if result.ID != ids[i] {
+			t.Errorf("result %d has ID %s, want %s", i, result.ID, ids[i])
+		}
+
+		numericID, _ := strconv.Atoi(result.ID)
+		var want apperror.Kind
+		switch {
+		case numericID < 1:
+			want = apperror.KindBadRequest
+		case numericID == 5:
+			want = apperror.KindForbidden
+		case numericID == 7:
+			want = apperror.KindNotFound
+		case numericID > batchSize && numericID <= 30:
+			want = apperror.KindUpstreamUnavailable
+		}
+		if got := kindOf(result.Err); got != want {
+			t.Errorf("result %s: error %v, want kind %q", result.ID, result.Err, want)
+		}
+		if want == "" && (result.Media == nil || result.Media.ID != result.ID) {
+			t.Errorf("result %s: media = %+v", result.ID, result.Media)
+		}
+		if want != "" && result.Media != nil {
+			t.Errorf("result %s: media = %+v alongside error %v", result.ID, result.Media, result.Err)
+		}
+	}
+
+	// Each ID is requested once, in chunks of batchSize, in input order.
+	var first, second []int
+	for id := 1; id <= 30; id++ {
+		if id <= batchSize {
+			first = append(first, id)
+		} else {
+			second = append(second, id)
+		}
+	}
+	if want := [][]int{first, second}; !reflect.DeepEqual(upstream.requests, want) {
+		t.Errorf("upstream requests = %v, want %v", upstream.requests, want)
+	}
+}
@@ -0,0 +1,49 @@
+package anilist
+
+import (
+	"context"
+
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+var _ provider.DiscoveryProvider = (*AniListBase)(nil)
+
+// Trending lists the anime trending on AniList right now.
+func (a *AniListBase) Trending(ctx context.Context, page int, perPage int) (*types.AnimePage, error) {
+	return a.Search(ctx, types.MediaFilter{
+		Sort:    []types.MediaSort{types.SortTrendingDesc},
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Popular lists the most popular anime of all time.
+func (a *AniListBase) Popular(ctx context.Context, page int, perPage int) (*types.AnimePage, error) {
+	return a.Search(ctx, types.MediaFilter{
+		Sort:    []types.MediaSort{types.SortPopularityDesc},
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Seasonal lists the anime of a season, most popular first.
+func (a *AniListBase) Seasonal(ctx context.Context, season types.Season, year int, page int, perPage int) (*types.AnimePage, error) {
+	return a.Search(ctx, types.MediaFilter{
+		Season:  season,
+		Year:    year,
+		Sort:    []types.MediaSort{types.SortPopularityDesc},
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// Upcoming lists announced anime that have not started airing, most anticipated first.
+func (a *AniListBase) Upcoming(ctx context.Context, page int, perPage int) (*types.AnimePage, error) {
+	return a.Search(ctx, types.MediaFilter{
+		Status:  types.StatusNotYetReleased,
+		Sort:    []types.MediaSort{types.SortPopularityDesc},
+		Page:    page,
+		PerPage: perPage,
+	})
+}
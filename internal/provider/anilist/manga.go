@@ -0,0 +1,176 @@
+package anilist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+var _ provider.MangaProvider = (*AniListBase)(nil)
+
+// mangaFields are selected on top of query for manga.
+const mangaFields = `
+chapters
+volumes
+startDate {
+  year
+}
+staff(sort: RELEVANCE, perPage: 10) {
+  edges {
+    role
+    node {
+      name {
+        full
+      }
+    }
+  }
+}
+`
+
+// MangaFormats are the manga formats listed by SearchManga.
+func (a *AniListBase) MangaFormats() []types.Format {
+	return []types.Format{
+		types.FormatManga,
+		types.FormatNovel,
+		types.FormatOneShot,
+	}
+}
+
+// SearchManga lists the manga matching filter; adult entries are excluded.
+func (a *AniListBase) SearchManga(ctx context.Context, filter types.MediaFilter) (*types.MangaPage, error) {
+	filter = normalizeFilter(filter, types.TypeManga, a.MangaFormats())
+
+	filterKey, _ := json.Marshal(filter)
+	cacheKey := cache.Key(a.ID(), "manga-search", strings.ToLower(string(filterKey)))
+	var cached types.MangaPage
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	var response struct {
+		Page struct {
+			PageInfo types.PageInfo `json:"pageInfo"`
+			Media    []types.Media  `json:"media"`
+		} `json:"Page"`
+	}
+
+	if err := a.graphql(ctx, pageQuery(a.query+mangaFields), filterVariables(filter), &response); err != nil {
+		return nil, err
+	}
+
+	result := &types.MangaPage{
+		PageInfo: response.Page.PageInfo,
+		Results:  []types.MangaInfo{},
+	}
+	for _, media := range response.Page.Media {
+		if media.IsAdult {
+			continue
+		}
+		result.Results = append(result.Results, a.mapMediaToMangaInfo(media))
+	}
+
+	cache.SetJSON(ctx, a.cache, cacheKey, result, cache.TTLSearch)
+	return result, nil
+}
+
+// GetManga returns the manga with the given AniList ID.
+func (a *AniListBase) GetManga(ctx context.Context, id string) (*types.MangaInfo, error) {
+	cacheKey := cache.Key(a.ID(), "manga", id)
+	var cached types.MangaInfo
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	graphqlQuery := `
+query ($id: Int) {
+  Media(id: $id, type: MANGA) {
+` + a.query + mangaFields + `
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"id": id,
+	}
+
+	var response struct {
+		Media types.Media `json:"Media"`
+	}
+
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
+		return nil, err
+	}
+
+	media := response.Media
+	if media.IsAdult {
+		return nil, apperror.Forbidden(a.ID(), "media is adult content")
+	}
+
+	mangaInfo := a.mapMediaToMangaInfo(media)
+	cache.SetJSON(ctx, a.cache, cacheKey, mangaInfo, cache.TTLMedia)
+	return &mangaInfo, nil
+}
+
+func (a *AniListBase) mapMediaToMangaInfo(media types.Media) types.MangaInfo {
+	// The anime mapping already covers every field the two types share.
+	anime := a.mapMediaToAnimeInfo(media)
+
+	var coverImage *string
+	if media.CoverImage.ExtraLarge != "" {
+		coverImage = &media.CoverImage.ExtraLarge
+	}
+
+	var year *int
+	if media.StartDate.Year != 0 {
+		year = &media.StartDate.Year
+	}
+
+	return types.MangaInfo{
+		ID:              anime.ID,
+		IDMal:           anime.IDMal,
+		Title:           anime.Title,
+		CoverImage:      coverImage,
+		BannerImage:     anime.BannerImage,
+		Popularity:      anime.Popularity,
+		Synonyms:        anime.Synonyms,
+		TotalChapters:   media.Chapters,
+		TotalVolumes:    media.Volumes,
+		Color:           anime.Color,
+		Status:          anime.Status,
+		Genres:          anime.Genres,
+		Rating:          anime.Rating,
+		Description:     anime.Description,
+		Format:          anime.Format,
+		Year:            year,
+		Type:            types.TypeManga,
+		CountryOfOrigin: anime.CountryOfOrigin,
+		Tags:            anime.Tags,
+		Artwork:         anime.Artwork,
+		Relations:       anime.Relations,
+		Characters:      anime.Characters,
+		Author:          authors(media.Staff),
+		Chapters:        []types.Chapter{},
+	}
+}
+
+// authors joins the names of the staff credited with the story or art.
+func authors(staff types.Staff) *string {
+	var names []string
+	for _, edge := range staff.Edges {
+		role := strings.ToLower(edge.Role)
+		if strings.Contains(role, "story") || strings.Contains(role, "art") || strings.Contains(role, "original creator") {
+			names = append(names, fmt.Sprintf("%s (%s)", edge.Node.Name.Full, edge.Role))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	joined := strings.Join(names, ", ")
+	return &joined
+}
@@ -0,0 +1,484 @@
+package anilist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+var _ provider.PeopleProvider = (*AniListBase)(nil)
+
+const pageInfoFields = `
+pageInfo {
+  total
+  perPage
+  currentPage
+  lastPage
+  hasNextPage
+}
+`
+
+const personFields = `
+id
+name {
+  full
+  native
+}
+image {
+  large
+}
+`
+
+// appearanceMediaFields are selected for the works a person appears in.
+const appearanceMediaFields = `
+id
+title {
+  romaji
+  english
+  native
+}
+format
+type
+isAdult
+coverImage {
+  large
+}
+`
+
+// appearanceEdge is an edge of a character's or staff member's media connection.
+type appearanceEdge struct {
+	CharacterRole string                `json:"characterRole"`
+	StaffRole     string                `json:"staffRole"`
+	Characters    []types.CharacterNode `json:"characters"`
+	Node          struct {
+		ID         int         `json:"id"`
+		Title      types.Title `json:"title"`
+		Format     string      `json:"format"`
+		Type       string      `json:"type"`
+		IsAdult    bool        `json:"isAdult"`
+		CoverImage types.Image `json:"coverImage"`
+	} `json:"node"`
+}
+
+type appearanceConnection struct {
+	PageInfo types.PageInfo   `json:"pageInfo"`
+	Edges    []appearanceEdge `json:"edges"`
+}
+
+// Characters lists the characters of an anime with their voice actors in
+// language, or in every language when it is empty. Main characters come first.
+func (a *AniListBase) Characters(ctx context.Context, mediaID string, language types.StaffLanguage, page int, perPage int) (*types.CharacterPage, error) {
+	cacheKey := cache.Key(a.ID(), "characters", mediaID, string(language), strconv.Itoa(page), strconv.Itoa(perPage))
+	var cached types.CharacterPage
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	languages := types.StaffLanguages
+	if language != "" {
+		languages = []types.StaffLanguage{language}
+	}
+
+	// voiceActors only returns one language per selection, so each language
+	// is selected under its own alias.
+	var voiceActors strings.Builder
+	for _, l := range languages {
+		fmt.Fprintf(&voiceActors, "%s: voiceActors(language: %s, sort: [RELEVANCE, ID]) {\n%s\nlanguageV2\n}\n", strings.ToLower(string(l)), l, personFields)
+	}
+
+	graphqlQuery := `
+query ($id: Int, $page: Int, $perPage: Int) {
+  Media(id: $id) {
+    isAdult
+    characters(page: $page, perPage: $perPage, sort: [ROLE, RELEVANCE, ID]) {
+` + pageInfoFields + `
+      edges {
+        role
+        node {
+` + personFields + `
+        }
+` + voiceActors.String() + `
+      }
+    }
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"id":      mediaID,
+		"page":    page,
+		"perPage": perPage,
+	}
+
+	var response struct {
+		Media struct {
+			IsAdult    bool `json:"isAdult"`
+			Characters struct {
+				PageInfo types.PageInfo               `json:"pageInfo"`
+				Edges    []map[string]json.RawMessage `json:"edges"`
+			} `json:"characters"`
+		} `json:"Media"`
+	}
+
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
+		return nil, err
+	}
+	if response.Media.IsAdult {
+		return nil, apperror.Forbidden(a.ID(), "media is adult content")
+	}
+
+	var characters types.Characters
+	for _, fields := range response.Media.Characters.Edges {
+		var edge types.CharacterEdge
+		if err := json.Unmarshal(fields["role"], &edge.Role); err != nil {
+			return nil, fmt.Errorf("failed to decode character role: %w", err)
+		}
+		if err := json.Unmarshal(fields["node"], &edge.Node); err != nil {
+			return nil, fmt.Errorf("failed to decode character: %w", err)
+		}
+		for _, l := range languages {
+			var nodes []types.StaffNode
+			if raw, ok := fields[strings.ToLower(string(l))]; ok {
+				if err := json.Unmarshal(raw, &nodes); err != nil {
+					return nil, fmt.Errorf("failed to decode voice actors: %w", err)
+				}
+			}
+			edge.VoiceActors = append(edge.VoiceActors, nodes...)
+		}
+		characters.Edges = append(characters.Edges, edge)
+	}
+
+	result := &types.CharacterPage{
+		PageInfo: response.Media.Characters.PageInfo,
+		Results:  a.mapCharacters(characters),
+	}
+	if result.Results == nil {
+		result.Results = []types.Character{}
+	}
+
+	cache.SetJSON(ctx, a.cache, cacheKey, result, cache.TTLMedia)
+	return result, nil
+}
+
+// Staff lists the staff credited on an anime, most relevant first.
+func (a *AniListBase) Staff(ctx context.Context, mediaID string, page int, perPage int) (*types.StaffPage, error) {
+	cacheKey := cache.Key(a.ID(), "staff", mediaID, strconv.Itoa(page), strconv.Itoa(perPage))
+	var cached types.StaffPage
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	graphqlQuery := `
+query ($id: Int, $page: Int, $perPage: Int) {
+  Media(id: $id) {
+    isAdult
+    staff(page: $page, perPage: $perPage, sort: [RELEVANCE, ID]) {
+` + pageInfoFields + `
+      edges {
+        role
+        node {
+` + personFields + `
+          languageV2
+          primaryOccupations
+        }
+      }
+    }
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"id":      mediaID,
+		"page":    page,
+		"perPage": perPage,
+	}
+
+	var response struct {
+		Media struct {
+			IsAdult bool        `json:"isAdult"`
+			Staff   types.Staff `json:"staff"`
+		} `json:"Media"`
+	}
+
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
+		return nil, err
+	}
+	if response.Media.IsAdult {
+		return nil, apperror.Forbidden(a.ID(), "media is adult content")
+	}
+
+	result := &types.StaffPage{
+		PageInfo: response.Media.Staff.PageInfo,
+		Results:  []types.StaffMember{},
+	}
+	for _, edge := range response.Media.Staff.Edges {
+		result.Results = append(result.Results, types.StaffMember{
+			ID:          fmt.Sprintf("%d", edge.Node.ID),
+			Name:        edge.Node.Name.Full,
+			NativeName:  edge.Node.Name.Native,
+			Image:       edge.Node.Image.Large,
+			Role:        edge.Role,
+			Language:    edge.Node.LanguageV2,
+			Occupations: nonNil(edge.Node.PrimaryOccupations),
+		})
+	}
+
+	cache.SetJSON(ctx, a.cache, cacheKey, result, cache.TTLMedia)
+	return result, nil
+}
+
+// GetCharacter returns a character and a page of the works they appear in,
+// most popular first.
+func (a *AniListBase) GetCharacter(ctx context.Context, id string, page int, perPage int) (*types.CharacterDetails, error) {
+	cacheKey := cache.Key(a.ID(), "character", id, strconv.Itoa(page), strconv.Itoa(perPage))
+	var cached types.CharacterDetails
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	graphqlQuery := `
+query ($id: Int, $page: Int, $perPage: Int) {
+  Character(id: $id) {
+    id
+    name {
+      full
+      native
+      alternative
+    }
+    image {
+      large
+    }
+    description
+    gender
+    age
+    dateOfBirth {
+      year
+      month
+      day
+    }
+    favourites
+    media(page: $page, perPage: $perPage, sort: [POPULARITY_DESC]) {
+` + pageInfoFields + `
+      edges {
+        characterRole
+        node {
+` + appearanceMediaFields + `
+        }
+      }
+    }
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"id":      id,
+		"page":    page,
+		"perPage": perPage,
+	}
+
+	var response struct {
+		Character struct {
+			types.CharacterNode
+			Media appearanceConnection `json:"media"`
+		} `json:"Character"`
+	}
+
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
+		return nil, err
+	}
+
+	character := response.Character
+	details := &types.CharacterDetails{
+		ID:               fmt.Sprintf("%d", character.ID),
+		Name:             character.Name.Full,
+		NativeName:       character.Name.Native,
+		AlternativeNames: nonNil(character.Name.Alternative),
+		Image:            character.Image.Large,
+		Description:      optionalDescription(character.Description),
+		Gender:           character.Gender,
+		Age:              character.Age,
+		DateOfBirth:      formatFuzzyDate(character.DateOfBirth),
+		Favourites:       character.Favourites,
+		Appearances:      mapAppearances(character.Media),
+	}
+
+	cache.SetJSON(ctx, a.cache, cacheKey, details, cache.TTLMedia)
+	return details, nil
+}
+
+// GetStaff returns a staff member with a page of the works they are credited
+// on and a page of the characters they voiced, most popular first.
+func (a *AniListBase) GetStaff(ctx context.Context, id string, page int, perPage int) (*types.StaffDetails, error) {
+	cacheKey := cache.Key(a.ID(), "staff-member", id, strconv.Itoa(page), strconv.Itoa(perPage))
+	var cached types.StaffDetails
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	graphqlQuery := `
+query ($id: Int, $page: Int, $perPage: Int) {
+  Staff(id: $id) {
+    id
+    name {
+      full
+      native
+      alternative
+    }
+    image {
+      large
+    }
+    description
+    languageV2
+    primaryOccupations
+    gender
+    age
+    homeTown
+    yearsActive
+    favourites
+    staffMedia(page: $page, perPage: $perPage, sort: [POPULARITY_DESC]) {
+` + pageInfoFields + `
+      edges {
+        staffRole
+        node {
+` + appearanceMediaFields + `
+        }
+      }
+    }
+    characterMedia(page: $page, perPage: $perPage, sort: [POPULARITY_DESC]) {
+` + pageInfoFields + `
+      edges {
+        characterRole
+        characters {
+` + personFields + `
+        }
+        node {
+` + appearanceMediaFields + `
+        }
+      }
+    }
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"id":      id,
+		"page":    page,
+		"perPage": perPage,
+	}
+
+	var response struct {
+		Staff struct {
+			types.StaffNode
+			StaffMedia     appearanceConnection `json:"staffMedia"`
+			CharacterMedia appearanceConnection `json:"characterMedia"`
+		} `json:"Staff"`
+	}
+
+	if err := a.graphql(ctx, graphqlQuery, variables, &response); err != nil {
+		return nil, err
+	}
+
+	staff := response.Staff
+	var age *int
+	if staff.Age != 0 {
+		age = &staff.Age
+	}
+
+	details := &types.StaffDetails{
+		ID:               fmt.Sprintf("%d", staff.ID),
+		Name:             staff.Name.Full,
+		NativeName:       staff.Name.Native,
+		AlternativeNames: nonNil(staff.Name.Alternative),
+		Image:            staff.Image.Large,
+		Description:      optionalDescription(staff.Description),
+		Language:         staff.LanguageV2,
+		Occupations:      nonNil(staff.PrimaryOccupations),
+		Gender:           staff.Gender,
+		Age:              age,
+		HomeTown:         staff.HomeTown,
+		YearsActive:      staff.YearsActive,
+		Favourites:       staff.Favourites,
+		Works:            mapAppearances(staff.StaffMedia),
+		Roles:            mapAppearances(staff.CharacterMedia),
+	}
+	if details.YearsActive == nil {
+		details.YearsActive = []int{}
+	}
+
+	cache.SetJSON(ctx, a.cache, cacheKey, details, cache.TTLMedia)
+	return details, nil
+}
+
+// mapAppearances maps a media connection, leaving out adult works.
+func mapAppearances(connection appearanceConnection) types.AppearancePage {
+	result := types.AppearancePage{
+		PageInfo: connection.PageInfo,
+		Results:  []types.Appearance{},
+	}
+	for _, edge := range connection.Edges {
+		if edge.Node.IsAdult {
+			continue
+		}
+
+		role := edge.CharacterRole
+		if edge.StaffRole != "" {
+			role = edge.StaffRole
+		}
+
+		appearance := types.Appearance{
+			ID:         fmt.Sprintf("%d", edge.Node.ID),
+			Title:      edge.Node.Title,
+			Format:     types.Format(edge.Node.Format),
+			Type:       types.MediaType(edge.Node.Type),
+			CoverImage: edge.Node.CoverImage.Large,
+			Role:       role,
+		}
+		for _, character := range edge.Characters {
+			appearance.Characters = append(appearance.Characters, types.Character{
+				ID:    fmt.Sprintf("%d", character.ID),
+				Name:  character.Name.Full,
+				Image: character.Image.Large,
+				Role:  edge.CharacterRole,
+			})
+		}
+		result.Results = append(result.Results, appearance)
+	}
+	return result
+}
+
+// optionalDescription strips the markup of an AniList description, returning
+// nil when there is none.
+func optionalDescription(description string) *string {
+	if description == "" {
+		return nil
+	}
+	stripped := stripHTMLTags(description)
+	return &stripped
+}
+
+// formatFuzzyDate formats the known parts of date as YYYY-MM-DD, MM-DD or YYYY.
+func formatFuzzyDate(date types.FuzzyDate) string {
+	switch {
+	case date.Year != 0 && date.Month != 0 && date.Day != 0:
+		return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+	case date.Month != 0 && date.Day != 0:
+		return fmt.Sprintf("%02d-%02d", date.Month, date.Day)
+	case date.Year != 0:
+		return fmt.Sprintf("%04d", date.Year)
+	}
+	return ""
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
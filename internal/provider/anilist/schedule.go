@@ -0,0 +1,146 @@
+package anilist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aniverse/internal/cache"
+	"aniverse/internal/types"
+)
+
+// maxSchedulePages bounds how many pages of 50 broadcasts Schedule fetches.
+const maxSchedulePages = 10
+
+// scheduleMediaFields is a lighter selection than query; a week of
+// broadcasts does not need characters and relations.
+const scheduleMediaFields = `
+id
+idMal
+title {
+  romaji
+  english
+  native
+}
+coverImage {
+  extraLarge
+  color
+}
+bannerImage
+season
+seasonYear
+type
+format
+status(version: 2)
+episodes
+duration
+genres
+isAdult
+meanScore
+popularity
+countryOfOrigin
+nextAiringEpisode {
+  episode
+  airingAt
+}
+`
+
+// scheduleQuery fetches one page of the broadcasts airing in a time range.
+const scheduleQuery = `
+query ($page: Int, $from: Int, $to: Int) {
+  Page(page: $page, perPage: 50) {
+    pageInfo {
+      hasNextPage
+    }
+    airingSchedules(airingAt_greater: $from, airingAt_lesser: $to, sort: TIME) {
+      episode
+      airingAt
+      media {
+` + scheduleMediaFields + `
+      }
+    }
+  }
+}
+`
+
+// schedulePage is one page of broadcasts, as cached.
+type schedulePage struct {
+	Entries     []types.ScheduleEntry `json:"entries"`
+	HasNextPage bool                  `json:"hasNextPage"`
+}
+
+// Schedule returns the broadcasts airing in [from, to), ordered by time. At
+// most maxSchedulePages pages are fetched; Truncated reports that more
+// broadcasts air in the range than were returned.
+func (a *AniListBase) Schedule(ctx context.Context, from time.Time, to time.Time) (*types.Schedule, error) {
+	schedule := &types.Schedule{Entries: []types.ScheduleEntry{}}
+	for page := 1; page <= maxSchedulePages; page++ {
+		result, err := a.schedulePage(ctx, from, to, page)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Entries = append(schedule.Entries, result.Entries...)
+
+		if !result.HasNextPage {
+			return schedule, nil
+		}
+	}
+	schedule.Truncated = true
+	return schedule, nil
+}
+
+// schedulePage fetches page 'page' of the broadcasts airing in [from, to).
+// Pages are cached on their own, so a failed page does not refetch the
+// ones before it.
+func (a *AniListBase) schedulePage(ctx context.Context, from time.Time, to time.Time, page int) (*schedulePage, error) {
+	cacheKey := cache.Key(a.ID(), "schedule", fmt.Sprint(from.Unix()), fmt.Sprint(to.Unix()), fmt.Sprint(page))
+	var cached schedulePage
+	if cache.GetJSON(ctx, a.cache, cacheKey, &cached) {
+		return &cached, nil
+	}
+
+	variables := map[string]interface{}{
+		"page": page,
+		// airingAt_greater is exclusive, so step back a second to include from.
+		"from": from.Unix() - 1,
+		"to":   to.Unix(),
+	}
+
+	var response struct {
+		Page struct {
+			PageInfo        types.PageInfo `json:"pageInfo"`
+			AiringSchedules []struct {
+				Episode  int         `json:"episode"`
+				AiringAt int64       `json:"airingAt"`
+				Media    types.Media `json:"media"`
+			} `json:"airingSchedules"`
+		} `json:"Page"`
+	}
+
+	if err := a.graphql(ctx, scheduleQuery, variables, &response); err != nil {
+		return nil, err
+	}
+
+	formats := make(map[string]bool)
+	for _, format := range a.Formats() {
+		formats[string(format)] = true
+	}
+
+	result := &schedulePage{
+		Entries:     []types.ScheduleEntry{},
+		HasNextPage: response.Page.PageInfo.HasNextPage,
+	}
+	for _, schedule := range response.Page.AiringSchedules {
+		if schedule.Media.IsAdult || !formats[schedule.Media.Format] {
+			continue
+		}
+		result.Entries = append(result.Entries, types.ScheduleEntry{
+			Episode:  schedule.Episode,
+			AiringAt: schedule.AiringAt,
+			Anime:    a.mapMediaToAnimeInfo(schedule.Media),
+		})
+	}
+
+	cache.SetJSON(ctx, a.cache, cacheKey, result, cache.TTLSchedule)
+	return result, nil
+}
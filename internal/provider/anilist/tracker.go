@@ -0,0 +1,300 @@
+package anilist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+var _ provider.Tracker = (*AniListBase)(nil)
+
+// ErrLoginDisabled is returned when no OAuth client is configured.
+var ErrLoginDisabled = apperror.New(apperror.KindUpstreamUnavailable, "anilist", "AniList login is not configured")
+
+// AuthorizeURL returns the AniList page where the user approves Aniverse.
+func (a *AniListBase) AuthorizeURL(state string) (string, error) {
+	if a.clientID == "" {
+		return "", ErrLoginDisabled
+	}
+
+	params := url.Values{}
+	params.Set("client_id", a.clientID)
+	params.Set("redirect_uri", a.redirectURL)
+	params.Set("response_type", "code")
+	params.Set("state", state)
+	return a.siteURL + "/api/v2/oauth/authorize?" + params.Encode(), nil
+}
+
+// Exchange trades an authorization code for an access token. AniList tokens
+// last a year and cannot be refreshed; state is not needed.
+func (a *AniListBase) Exchange(ctx context.Context, code string, state string) (*types.OAuthToken, error) {
+	if a.clientID == "" {
+		return nil, ErrLoginDisabled
+	}
+
+	payload := map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     a.clientID,
+		"client_secret": a.clientSecret,
+		"redirect_uri":  a.redirectURL,
+		"code":          code,
+	}
+
+	resp, err := a.client.PostJSON(ctx, a.siteURL+"/api/v2/oauth/token", map[string]string{"Accept": "application/json"}, payload)
+	if err != nil {
+		return nil, apperror.Upstream(a.ID(), fmt.Errorf("failed to exchange code: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// An invalid or reused code is the user's to retry, not an outage.
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+			return nil, apperror.New(apperror.KindUnauthorized, a.ID(), "AniList rejected the authorization code")
+		}
+		return nil, apperror.FromStatus(a.ID(), resp.StatusCode)
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, apperror.UpstreamUnavailable(a.ID(), fmt.Errorf("failed to decode token response: %w", err))
+	}
+
+	token := &types.OAuthToken{AccessToken: response.AccessToken}
+	if response.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// Refresh always fails; AniList tokens cannot be refreshed, so the user has
+// to sign in again once theirs expires.
+func (a *AniListBase) Refresh(ctx context.Context, token *types.OAuthToken) (*types.OAuthToken, error) {
+	return nil, apperror.New(apperror.KindUnauthorized, a.ID(), "AniList login expired")
+}
+
+// Viewer returns the user the token belongs to.
+func (a *AniListBase) Viewer(ctx context.Context, token *types.OAuthToken) (*types.Viewer, error) {
+	graphqlQuery := `
+query {
+  Viewer {
+    id
+    name
+    avatar {
+      large
+    }
+  }
+}
+`
+
+	var response struct {
+		Viewer struct {
+			ID     int         `json:"id"`
+			Name   string      `json:"name"`
+			Avatar types.Image `json:"avatar"`
+		} `json:"Viewer"`
+	}
+
+	if err := a.graphqlAs(ctx, token.AccessToken, graphqlQuery, nil, &response); err != nil {
+		return nil, err
+	}
+
+	return &types.Viewer{
+		ID:     fmt.Sprintf("%d", response.Viewer.ID),
+		Name:   response.Viewer.Name,
+		Avatar: response.Viewer.Avatar.Large,
+	}, nil
+}
+
+// SaveProgress records that the user watched up to episode progress. The
+// entry is only ever moved forward, so rewatching an early episode keeps the
+// user's progress; it is marked completed on the last episode.
+func (a *AniListBase) SaveProgress(ctx context.Context, token *types.OAuthToken, anime *types.AnimeInfo, progress int) error {
+	entryQuery := `
+query ($id: Int) {
+  Media(id: $id) {
+    mediaListEntry {
+      progress
+      status
+    }
+  }
+}
+`
+
+	var current struct {
+		Media struct {
+			MediaListEntry *struct {
+				Progress int              `json:"progress"`
+				Status   types.ListStatus `json:"status"`
+			} `json:"mediaListEntry"`
+		} `json:"Media"`
+	}
+
+	if err := a.graphqlAs(ctx, token.AccessToken, entryQuery, map[string]interface{}{"id": anime.ID}, &current); err != nil {
+		return err
+	}
+
+	status := types.ListCurrent
+	if entry := current.Media.MediaListEntry; entry != nil {
+		if progress <= entry.Progress {
+			return nil
+		}
+		if entry.Status == types.ListRepeating {
+			status = types.ListRepeating
+		}
+	}
+	if anime.TotalEpisodes > 0 && progress >= anime.TotalEpisodes {
+		status = types.ListCompleted
+	}
+
+	mutation := `
+mutation ($mediaId: Int, $progress: Int, $status: MediaListStatus) {
+  SaveMediaListEntry(mediaId: $mediaId, progress: $progress, status: $status) {
+    id
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"mediaId":  anime.ID,
+		"progress": progress,
+		"status":   status,
+	}
+
+	var response struct {
+		SaveMediaListEntry struct {
+			ID int `json:"id"`
+		} `json:"SaveMediaListEntry"`
+	}
+	return a.graphqlAs(ctx, token.AccessToken, mutation, variables, &response)
+}
+
+// UpdateEntry adds the anime to the user's list or changes its entry. Score
+// is out of 10, whatever scoring system the user picked on AniList.
+func (a *AniListBase) UpdateEntry(ctx context.Context, token *types.OAuthToken, anime *types.AnimeInfo, update types.ListEntryUpdate) (*types.ListEntry, error) {
+	mutation := `
+mutation ($mediaId: Int, $status: MediaListStatus, $progress: Int, $scoreRaw: Int) {
+  SaveMediaListEntry(mediaId: $mediaId, status: $status, progress: $progress, scoreRaw: $scoreRaw) {
+    status
+    progress
+    score(format: POINT_10_DECIMAL)
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"mediaId": anime.ID,
+	}
+	if update.Status != "" {
+		variables["status"] = update.Status
+	}
+	if update.Progress != nil {
+		variables["progress"] = *update.Progress
+	}
+	if update.Score != nil {
+		variables["scoreRaw"] = int(*update.Score * 10)
+	}
+
+	var response struct {
+		SaveMediaListEntry struct {
+			Status   types.ListStatus `json:"status"`
+			Progress int              `json:"progress"`
+			Score    float64          `json:"score"`
+		} `json:"SaveMediaListEntry"`
+	}
+
+	if err := a.graphqlAs(ctx, token.AccessToken, mutation, variables, &response); err != nil {
+		return nil, err
+	}
+
+	return &types.ListEntry{
+		Status:   response.SaveMediaListEntry.Status,
+		Progress: response.SaveMediaListEntry.Progress,
+		Score:    response.SaveMediaListEntry.Score,
+		Anime:    *anime,
+	}, nil
+}
+
+// MediaLists returns the user's anime lists, or only the list with the given
+// status when it is set.
+func (a *AniListBase) MediaLists(ctx context.Context, token *types.OAuthToken, status types.ListStatus) ([]types.MediaList, error) {
+	viewer, err := a.Viewer(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lists can hold hundreds of entries, so the lighter schedule selection
+	// keeps the query within AniList's complexity limit.
+	graphqlQuery := `
+query ($userId: Int, $status: MediaListStatus) {
+  MediaListCollection(userId: $userId, type: ANIME, status: $status) {
+    lists {
+      name
+      status
+      entries {
+        status
+        progress
+        score(format: POINT_10_DECIMAL)
+        media {
+` + scheduleMediaFields + `
+        }
+      }
+    }
+  }
+}
+`
+
+	variables := map[string]interface{}{
+		"userId": viewer.ID,
+	}
+	if status != "" {
+		variables["status"] = status
+	}
+
+	var response struct {
+		MediaListCollection struct {
+			Lists []struct {
+				Name    string           `json:"name"`
+				Status  types.ListStatus `json:"status"`
+				Entries []struct {
+					Status   types.ListStatus `json:"status"`
+					Progress int              `json:"progress"`
+					Score    float64          `json:"score"`
+					Media    types.Media      `json:"media"`
+				} `json:"entries"`
+			} `json:"lists"`
+		} `json:"MediaListCollection"`
+	}
+
+	if err := a.graphqlAs(ctx, token.AccessToken, graphqlQuery, variables, &response); err != nil {
+		return nil, err
+	}
+
+	lists := []types.MediaList{}
+	for _, list := range response.MediaListCollection.Lists {
+		mediaList := types.MediaList{
+			Name:    list.Name,
+			Status:  list.Status,
+			Entries: []types.ListEntry{},
+		}
+		for _, entry := range list.Entries {
+			mediaList.Entries = append(mediaList.Entries, types.ListEntry{
+				Status:   entry.Status,
+				Progress: entry.Progress,
+				Score:    entry.Score,
+				Anime:    a.mapMediaToAnimeInfo(entry.Media),
+			})
+		}
+		lists = append(lists, mediaList)
+	}
+	return lists, nil
+}
@@ -1,15 +1,101 @@
 package provider
 
 import (
+	"context"
+	"time"
+
 	"aniverse/internal/types"
 )
 
+// BaseProvider is implemented by every provider registered in a Registry.
 type BaseProvider interface {
 	ID() string
 	URL() string
 	Formats() []types.Format
 	NeedsProxy() bool
 	UseGoogleTranslate() bool
-	Search(query string, mediaType types.MediaType, formats []types.Format, page int, perPage int) ([]types.AnimeInfo, error)
-	GetMedia(id string) (*types.AnimeInfo, error)
+}
+
+// MetaProvider supplies anime metadata such as titles, artwork and relations.
+type MetaProvider interface {
+	BaseProvider
+	Search(ctx context.Context, filter types.MediaFilter) (*types.AnimePage, error)
+	GetMedia(ctx context.Context, id string) (*types.AnimeInfo, error)
+}
+
+// BatchMetaProvider is a MetaProvider that can fetch many anime at once.
+// Results are in the order of ids, and one ID failing leaves the rest intact.
+type BatchMetaProvider interface {
+	MetaProvider
+	GetMediaBatch(ctx context.Context, ids []string) []types.MediaResult
+}
+
+// DiscoveryProvider is a MetaProvider that can also list anime without a
+// search query, e.g. for a home page, and the airing schedule.
+type DiscoveryProvider interface {
+	MetaProvider
+	Trending(ctx context.Context, page int, perPage int) (*types.AnimePage, error)
+	Popular(ctx context.Context, page int, perPage int) (*types.AnimePage, error)
+	Seasonal(ctx context.Context, season types.Season, year int, page int, perPage int) (*types.AnimePage, error)
+	Upcoming(ctx context.Context, page int, perPage int) (*types.AnimePage, error)
+	Schedule(ctx context.Context, from time.Time, to time.Time) (*types.Schedule, error)
+}
+
+// MangaProvider supplies manga metadata. MangaFormats lists the formats
+// SearchManga accepts, as Formats does for anime.
+type MangaProvider interface {
+	BaseProvider
+	MangaFormats() []types.Format
+	SearchManga(ctx context.Context, filter types.MediaFilter) (*types.MangaPage, error)
+	GetManga(ctx context.Context, id string) (*types.MangaInfo, error)
+}
+
+// PeopleProvider lists the characters and staff of an anime and describes
+// the people involved, with the other works they appear in.
+type PeopleProvider interface {
+	BaseProvider
+	Characters(ctx context.Context, mediaID string, language types.StaffLanguage, page int, perPage int) (*types.CharacterPage, error)
+	Staff(ctx context.Context, mediaID string, page int, perPage int) (*types.StaffPage, error)
+	GetCharacter(ctx context.Context, id string, page int, perPage int) (*types.CharacterDetails, error)
+	GetStaff(ctx context.Context, id string, page int, perPage int) (*types.StaffDetails, error)
+}
+
+// Tracker syncs watch progress with a user's list on a tracking site. Users
+// sign in with OAuth: AuthorizeURL starts the flow and Exchange trades the
+// callback's code for a token, which the other methods act on behalf of.
+// Refresh renews an expired token where the site supports it.
+type Tracker interface {
+	BaseProvider
+	AuthorizeURL(state string) (string, error)
+	Exchange(ctx context.Context, code string, state string) (*types.OAuthToken, error)
+	Refresh(ctx context.Context, token *types.OAuthToken) (*types.OAuthToken, error)
+	Viewer(ctx context.Context, token *types.OAuthToken) (*types.Viewer, error)
+	SaveProgress(ctx context.Context, token *types.OAuthToken, anime *types.AnimeInfo, progress int) error
+	UpdateEntry(ctx context.Context, token *types.OAuthToken, anime *types.AnimeInfo, update types.ListEntryUpdate) (*types.ListEntry, error)
+	MediaLists(ctx context.Context, token *types.OAuthToken, status types.ListStatus) ([]types.MediaList, error)
+}
+
+// EpisodeProvider searches a streaming site and lists the episodes of a show.
+type EpisodeProvider interface {
+	BaseProvider
+	Search(ctx context.Context, query string) ([]types.AnimeInfo, error)
+	FetchEpisodes(ctx context.Context, id string) ([]types.Episode, error)
+}
+
+// SourceProvider resolves an episode ID into the embed URL of its stream.
+// Sites mirror episodes on several servers: GetServers lists them, and
+// GetSource takes one of their names, or "" for the site's default.
+type SourceProvider interface {
+	BaseProvider
+	GetServers(ctx context.Context, episodeID string) ([]types.EpisodeServer, error)
+	GetSource(ctx context.Context, episodeID string, server types.StreamingServer) (string, error)
+}
+
+// ChapterProvider searches a manga site, lists the chapters of a manga and
+// the page images of a chapter.
+type ChapterProvider interface {
+	BaseProvider
+	SearchManga(ctx context.Context, query string) ([]types.MangaInfo, error)
+	FetchChapters(ctx context.Context, id string) ([]types.Chapter, error)
+	FetchPages(ctx context.Context, chapterID string) ([]types.Page, error)
 }
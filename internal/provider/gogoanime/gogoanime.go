@@ -1,6 +1,8 @@
 package gogoanime
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,23 +10,34 @@ import (
 	"strconv"
 	"strings"
 
-	"aniverse/internal/extractor"
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
+	"aniverse/internal/httpclient"
+	"aniverse/internal/provider"
 	"aniverse/internal/types"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
+var (
+	_ provider.EpisodeProvider = (*GogoAnime)(nil)
+	_ provider.SourceProvider  = (*GogoAnime)(nil)
+)
+
 type GogoAnime struct {
 	baseURL string
 	ajaxURL string
-	gogoCDN *extractor.Gogocdn
+	cache   cache.Store
+	client  *httpclient.Client
 }
 
-func NewGogoAnime() *GogoAnime {
+func NewGogoAnime(cfg config.GogoAnimeConfig, store cache.Store, client *httpclient.Client) *GogoAnime {
 	return &GogoAnime{
-		baseURL: "https://gogoanime3.co", // Ensure this is the correct base URL
-		ajaxURL: "https://ajax.gogocdn.net",
-		gogoCDN: extractor.NewGogocdn(nil),
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		ajaxURL: strings.TrimSuffix(cfg.AjaxURL, "/"),
+		cache:   store,
+		client:  client,
 	}
 }
 
@@ -47,20 +60,33 @@ func (g *GogoAnime) Formats() []types.Format {
 	}
 }
 
-func (g *GogoAnime) Search(query string) ([]types.AnimeInfo, error) {
+func (g *GogoAnime) NeedsProxy() bool {
+	return true
+}
+
+func (g *GogoAnime) UseGoogleTranslate() bool {
+	return false
+}
+
+func (g *GogoAnime) Search(ctx context.Context, query string) ([]types.AnimeInfo, error) {
+	cacheKey := cache.Key(g.ID(), "search", strings.ToLower(query))
 	results := []types.AnimeInfo{}
+	if cache.GetJSON(ctx, g.cache, cacheKey, &results) {
+		return results, nil
+	}
+
 	encodedQuery := url.QueryEscape(query)
 	searchURL := fmt.Sprintf("%s/search.html?keyword=%s", g.baseURL, encodedQuery)
 	log.Printf("Searching GogoAnime with URL: %s", searchURL) // Added logging for debugging
 
-	resp, err := http.Get(searchURL)
+	resp, err := g.client.Get(ctx, searchURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, apperror.Upstream(g.ID(), fmt.Errorf("failed to make request: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+		return nil, apperror.FromStatus(g.ID(), resp.StatusCode)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
@@ -107,10 +133,11 @@ func (g *GogoAnime) Search(query string) ([]types.AnimeInfo, error) {
 		results = append(results, animeInfo)
 	})
 
+	cache.SetJSON(ctx, g.cache, cacheKey, results, cache.TTLSearch)
 	return results, nil
 }
 
-func (g *GogoAnime) FetchEpisodes(id string) ([]types.Episode, error) {
+func (g *GogoAnime) FetchEpisodes(ctx context.Context, id string) ([]types.Episode, error) {
 	log.Printf("Fetching episodes from ID: %s", id)
 
 	// Ensure ID starts with a "/"
@@ -118,14 +145,20 @@ func (g *GogoAnime) FetchEpisodes(id string) ([]types.Episode, error) {
 		id = "/category/" + id
 	}
 
-	resp, err := http.Get(g.baseURL + id)
+	cacheKey := cache.Key(g.ID(), "episodes", id)
+	var cached []types.Episode
+	if cache.GetJSON(ctx, g.cache, cacheKey, &cached) {
+		return cached, nil
+	}
+
+	resp, err := g.client.Get(ctx, g.baseURL+id, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, apperror.Upstream(g.ID(), fmt.Errorf("failed to make request: %w", err))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+		return nil, apperror.FromStatus(g.ID(), resp.StatusCode)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
@@ -141,14 +174,14 @@ func (g *GogoAnime) FetchEpisodes(id string) ([]types.Episode, error) {
 	ajaxURL := fmt.Sprintf("%s/ajax/load-list-episode?ep_start=%s&ep_end=%s&id=%s&default_ep=0&alias=%s", g.ajaxURL, epStart, epEnd, movieID, alias)
 	log.Printf("Constructed AJAX URL: %s", ajaxURL)
 
-	ajaxResp, err := http.Get(ajaxURL)
+	ajaxResp, err := g.client.Get(ctx, ajaxURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make AJAX request: %w", err)
+		return nil, apperror.Upstream(g.ID(), fmt.Errorf("failed to make AJAX request: %w", err))
 	}
 	defer ajaxResp.Body.Close()
 
 	if ajaxResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-200 AJAX response code: %d", ajaxResp.StatusCode)
+		return nil, apperror.FromStatus(g.ID(), ajaxResp.StatusCode)
 	}
 
 	ajaxDoc, err := goquery.NewDocumentFromReader(ajaxResp.Body)
@@ -187,32 +220,124 @@ func (g *GogoAnime) FetchEpisodes(id string) ([]types.Episode, error) {
 		episodes[i], episodes[j] = episodes[j], episodes[i]
 	}
 
+	cache.SetJSON(ctx, g.cache, cacheKey, episodes, cache.TTLEpisodes)
 	return episodes, nil
 }
 
-func (g *GogoAnime) GetSource(episodeURL string) (string, error) {
-	resp, err := http.Get(episodeURL)
+// serverClasses maps the class of an entry in the server list of an episode
+// page to the server it links to. Other servers are named by their label.
+var serverClasses = map[string]types.StreamingServer{
+	"anime":    types.ServerGogoCDN,
+	"vidcdn":   types.ServerVidStreaming,
+	"streamsb": types.ServerStreamSB,
+}
+
+// GetServers lists the servers of an episode ID such as "/one-piece-episode-1"
+// in the order the episode page offers them.
+func (g *GogoAnime) GetServers(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
+	doc, err := g.episodePage(ctx, episodeID)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch episode page: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return episodeServers(doc), nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+// GetSource returns the embed URL of the stream for an episode ID such as
+// "/one-piece-episode-1" on server, or of the page's own player if server is "".
+func (g *GogoAnime) GetSource(ctx context.Context, episodeID string, server types.StreamingServer) (string, error) {
+	doc, err := g.episodePage(ctx, episodeID)
+	if err != nil {
+		return "", err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse episode page: %w", err)
+	if server != "" {
+		for _, s := range episodeServers(doc) {
+			if strings.EqualFold(string(s.Name), string(server)) {
+				return s.URL, nil
+			}
+		}
+		return "", apperror.NotFound(g.ID(), "Server %s is not available for %s.", server, episodeID)
 	}
 
 	// Example: Find the iframe that contains the streaming link
 	iframeSrc, exists := doc.Find("iframe").Attr("src")
 	if !exists {
-		return "", fmt.Errorf("no iframe src found in episode page")
+		return "", apperror.ExtractionFailed(g.ID(), errors.New("no iframe src found in episode page"))
+	}
+
+	return embedURL(iframeSrc), nil
+}
+
+// episodePage fetches and parses the page of an episode.
+func (g *GogoAnime) episodePage(ctx context.Context, episodeID string) (*goquery.Document, error) {
+	parsedBase, err := url.Parse(strings.TrimSpace(g.baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+
+	parsedEpisode, err := url.Parse(strings.TrimSpace(episodeID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse episode ID: %w", err)
 	}
 
-	return iframeSrc, nil
+	// Construct the GogoAnime episode URL
+	episodeURL := parsedBase.ResolveReference(parsedEpisode).String()
+	log.Printf("Constructed Episode URL: %s", episodeURL)
+
+	resp, err := g.client.Get(ctx, episodeURL, nil)
+	if err != nil {
+		return nil, apperror.Upstream(g.ID(), fmt.Errorf("failed to fetch episode page: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperror.FromStatus(g.ID(), resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse episode page: %w", err)
+	}
+	return doc, nil
+}
+
+// episodeServers reads the server list of an episode page.
+func episodeServers(doc *goquery.Document) []types.EpisodeServer {
+	servers := []types.EpisodeServer{}
+	doc.Find("div.anime_muti_link ul li").Each(func(i int, s *goquery.Selection) {
+		link := s.Find("a")
+		video, exists := link.Attr("data-video")
+		if !exists || strings.TrimSpace(video) == "" {
+			return
+		}
+
+		var name types.StreamingServer
+		for _, class := range strings.Fields(s.AttrOr("class", "")) {
+			if server, ok := serverClasses[class]; ok {
+				name = server
+				break
+			}
+		}
+		if name == "" {
+			label := strings.TrimSpace(strings.Replace(link.Text(), link.Find("span").Text(), "", 1))
+			if label == "" {
+				return
+			}
+			name = types.StreamingServer(label)
+		}
+
+		servers = append(servers, types.EpisodeServer{Name: name, URL: embedURL(video)})
+	})
+	return servers
+}
+
+// embedURL makes the protocol-relative embed URLs of episode pages absolute.
+func embedURL(link string) string {
+	link = strings.TrimSpace(link)
+	if strings.HasPrefix(link, "//") {
+		return "https:" + link
+	}
+	return link
 }
 
 // extractYear extracts the year from a string like "Released: 2021".
@@ -1,6 +1,7 @@
 package mal
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,20 +9,92 @@ import (
 	"strconv"
 	"strings"
 
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
+	"aniverse/internal/httpclient"
+	"aniverse/internal/types"
+	"aniverse/internal/util"
+
 	"github.com/PuerkitoBio/goquery"
 )
 
 type MyAnimeList struct {
 	BaseURL string
+	apiURL  string
+	cache   cache.Store
+	client  *httpclient.Client
+
+	// OAuth client credentials; login is disabled without a client ID.
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	verifiers    *util.StateStore
 }
 
-func NewMyAnimeList() *MyAnimeList {
+func NewMyAnimeList(cfg config.MALConfig, store cache.Store, client *httpclient.Client) *MyAnimeList {
 	return &MyAnimeList{
-		BaseURL: "https://myanimelist.net",
+		BaseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		apiURL:  strings.TrimSuffix(cfg.APIURL, "/"),
+		cache:   store,
+		client:  client,
+
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  cfg.RedirectURL,
+		verifiers:    util.NewStateStore(loginTTL),
 	}
 }
 
-func (m *MyAnimeList) GetEpisodeTitles(malID string, animeName string, episodeNum int) (map[int]string, error) {
+func (m *MyAnimeList) ID() string {
+	return "mal"
+}
+
+func (m *MyAnimeList) URL() string {
+	return m.BaseURL
+}
+
+func (m *MyAnimeList) Formats() []types.Format {
+	return []types.Format{
+		types.FormatMovie,
+		types.FormatONA,
+		types.FormatOVA,
+		types.FormatSpecial,
+		types.FormatTV,
+		types.FormatTVShort,
+	}
+}
+
+func (m *MyAnimeList) NeedsProxy() bool {
+	return false
+}
+
+func (m *MyAnimeList) UseGoogleTranslate() bool {
+	return false
+}
+
+func (m *MyAnimeList) GetEpisodeTitles(ctx context.Context, malID string, animeName string, episodeNum int) (map[int]string, error) {
+	cacheKey := cache.Key("mal", "episodes", malID)
+	episodeTitles := make(map[int]string)
+	if !cache.GetJSON(ctx, m.cache, cacheKey, &episodeTitles) {
+		var err error
+		episodeTitles, err = m.fetchEpisodeTitles(ctx, malID, animeName)
+		if err != nil {
+			return nil, err
+		}
+		cache.SetJSON(ctx, m.cache, cacheKey, episodeTitles, cache.TTLEpisodeTitles)
+	}
+
+	// If the requested episodeNum exists in the map, return just that
+	if title, ok := episodeTitles[episodeNum]; ok {
+		return map[int]string{episodeNum: title}, nil
+	}
+
+	return episodeTitles, nil
+}
+
+// fetchEpisodeTitles scrapes every episode title listed on the MAL episode page.
+func (m *MyAnimeList) fetchEpisodeTitles(ctx context.Context, malID string, animeName string) (map[int]string, error) {
 	// Normalize the anime name for URL safety
 	normalizedAnimeName := NormalizeAnimeTitle(animeName)
 
@@ -29,20 +102,20 @@ func (m *MyAnimeList) GetEpisodeTitles(malID string, animeName string, episodeNu
 	url := fmt.Sprintf("%s/anime/%s/%s/episode", m.BaseURL, malID, normalizedAnimeName)
 
 	// Make HTTP request to the URL
-	resp, err := http.Get(url)
+	resp, err := m.client.Get(ctx, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch MAL episode page: %v", err)
+		return nil, apperror.Upstream("mal", fmt.Errorf("failed to fetch MAL episode page: %w", err))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("received non-200 response code: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperror.FromStatus("mal", resp.StatusCode)
 	}
 
 	// Parse the HTML document
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse MAL episode page: %v", err)
+		return nil, fmt.Errorf("failed to parse MAL episode page: %w", err)
 	}
 
 	// Map to store episode number and title
@@ -72,12 +145,7 @@ func (m *MyAnimeList) GetEpisodeTitles(malID string, animeName string, episodeNu
 	})
 
 	if len(episodeTitles) == 0 {
-		return nil, fmt.Errorf("no episode titles found on MAL page")
-	}
-
-	// If the requested episodeNum exists in the map, return just that
-	if title, ok := episodeTitles[episodeNum]; ok {
-		return map[int]string{episodeNum: title}, nil
+		return nil, apperror.NotFound("mal", "no episode titles found on MAL page")
 	}
 
 	return episodeTitles, nil
@@ -89,3 +157,51 @@ func NormalizeAnimeTitle(animeName string) string {
 	normalized = strings.ReplaceAll(normalized, " ", "_")
 	return normalized
 }
+
+// GetMangaSerialization returns the magazines a manga is serialized in, as
+// listed on its MAL page. AniList has no publisher field, so this fills
+// MangaInfo.Publisher.
+func (m *MyAnimeList) GetMangaSerialization(ctx context.Context, malID string) (string, error) {
+	cacheKey := cache.Key("mal", "serialization", malID)
+	var serialization string
+	if cache.GetJSON(ctx, m.cache, cacheKey, &serialization) {
+		return serialization, nil
+	}
+
+	url := fmt.Sprintf("%s/manga/%s", m.BaseURL, malID)
+	resp, err := m.client.Get(ctx, url, nil)
+	if err != nil {
+		return "", apperror.Upstream("mal", fmt.Errorf("failed to fetch MAL manga page: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", apperror.FromStatus("mal", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse MAL manga page: %w", err)
+	}
+
+	// The sidebar lists "Serialization: <a>Magazine</a>, ..." next to a dark_text label.
+	var magazines []string
+	doc.Find("span.dark_text").Each(func(i int, s *goquery.Selection) {
+		if strings.TrimSpace(s.Text()) != "Serialization:" {
+			return
+		}
+		s.Parent().Find("a").Each(func(i int, a *goquery.Selection) {
+			if name := strings.TrimSpace(a.Text()); name != "" {
+				magazines = append(magazines, name)
+			}
+		})
+	})
+
+	if len(magazines) == 0 {
+		return "", apperror.NotFound("mal", "no serialization found on MAL page")
+	}
+
+	serialization = strings.Join(magazines, ", ")
+	cache.SetJSON(ctx, m.cache, cacheKey, serialization, cache.TTLMedia)
+	return serialization, nil
+}
@@ -0,0 +1,430 @@
+package mal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+	"aniverse/internal/util"
+)
+
+var _ provider.Tracker = (*MyAnimeList)(nil)
+
+// loginTTL is how long a user has to approve Aniverse on MAL.
+const loginTTL = 10 * time.Minute
+
+// maxListPages bounds how many pages of 1000 entries MediaLists reads.
+const maxListPages = 10
+
+// ErrLoginDisabled is returned when no OAuth client is configured.
+var ErrLoginDisabled = apperror.New(apperror.KindUpstreamUnavailable, "mal", "MyAnimeList login is not configured")
+
+// listNodeFields are requested for every anime on a user's list.
+const listNodeFields = "list_status,num_episodes,alternative_titles,media_type,mean,start_season,status"
+
+// malStatuses maps list statuses to MAL's, in the order MAL shows its lists.
+// MAL has no repeating status; a rewatch is "watching" with is_rewatching.
+var malStatuses = []struct {
+	status types.ListStatus
+	mal    string
+	name   string
+}{
+	{types.ListCurrent, "watching", "Watching"},
+	{types.ListCompleted, "completed", "Completed"},
+	{types.ListPaused, "on_hold", "On Hold"},
+	{types.ListDropped, "dropped", "Dropped"},
+	{types.ListPlanning, "plan_to_watch", "Plan to Watch"},
+}
+
+type animeNode struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	MainPicture struct {
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"main_picture"`
+	AlternativeTitles struct {
+		Synonyms []string `json:"synonyms"`
+		En       string   `json:"en"`
+		Ja       string   `json:"ja"`
+	} `json:"alternative_titles"`
+	MediaType   string  `json:"media_type"`
+	NumEpisodes int     `json:"num_episodes"`
+	Status      string  `json:"status"`
+	Mean        float64 `json:"mean"`
+	StartSeason struct {
+		Year   int    `json:"year"`
+		Season string `json:"season"`
+	} `json:"start_season"`
+}
+
+type listStatus struct {
+	Status             string  `json:"status"`
+	Score              float64 `json:"score"`
+	NumEpisodesWatched int     `json:"num_episodes_watched"`
+	IsRewatching       bool    `json:"is_rewatching"`
+}
+
+// AuthorizeURL returns the MAL page where the user approves Aniverse. A PKCE
+// verifier is kept under state until Exchange; MAL only supports the plain
+// challenge method, so the challenge is the verifier itself.
+func (m *MyAnimeList) AuthorizeURL(state string) (string, error) {
+	if m.clientID == "" {
+		return "", ErrLoginDisabled
+	}
+
+	verifier, err := util.GenerateCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+	m.verifiers.StoreCodeVerifier(state, verifier)
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", m.clientID)
+	params.Set("redirect_uri", m.redirectURL)
+	params.Set("state", state)
+	params.Set("code_challenge", verifier)
+	params.Set("code_challenge_method", "plain")
+	return m.BaseURL + "/v1/oauth2/authorize?" + params.Encode(), nil
+}
+
+// Exchange trades an authorization code and the verifier kept for state for
+// a token.
+func (m *MyAnimeList) Exchange(ctx context.Context, code string, state string) (*types.OAuthToken, error) {
+	if m.clientID == "" {
+		return nil, ErrLoginDisabled
+	}
+
+	verifier, ok := m.verifiers.GetCodeVerifier(state)
+	if !ok {
+		return nil, apperror.BadRequest("MyAnimeList login expired. Start again at /auth/mal/login.")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", m.redirectURL)
+	form.Set("code_verifier", verifier)
+	return m.requestToken(ctx, form)
+}
+
+// Refresh trades the refresh token for a new token.
+func (m *MyAnimeList) Refresh(ctx context.Context, token *types.OAuthToken) (*types.OAuthToken, error) {
+	if token.RefreshToken == "" {
+		return nil, apperror.New(apperror.KindUnauthorized, m.ID(), "MyAnimeList login expired")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", token.RefreshToken)
+	return m.requestToken(ctx, form)
+}
+
+// requestToken POSTs a grant to the token endpoint with the client credentials.
+func (m *MyAnimeList) requestToken(ctx context.Context, form url.Values) (*types.OAuthToken, error) {
+	form.Set("client_id", m.clientID)
+	if m.clientSecret != "" {
+		form.Set("client_secret", m.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.BaseURL+"/v1/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, apperror.Upstream(m.ID(), fmt.Errorf("failed to request token: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// An invalid, reused or revoked grant is the user's to retry, not an outage.
+		if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+			return nil, apperror.New(apperror.KindUnauthorized, m.ID(), "MyAnimeList rejected the grant")
+		}
+		return nil, apperror.FromStatus(m.ID(), resp.StatusCode)
+	}
+
+	var response struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, apperror.UpstreamUnavailable(m.ID(), fmt.Errorf("failed to decode token response: %w", err))
+	}
+
+	token := &types.OAuthToken{
+		AccessToken:  response.AccessToken,
+		RefreshToken: response.RefreshToken,
+	}
+	if response.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// Viewer returns the user the token belongs to.
+func (m *MyAnimeList) Viewer(ctx context.Context, token *types.OAuthToken) (*types.Viewer, error) {
+	var response struct {
+		ID      int    `json:"id"`
+		Name    string `json:"name"`
+		Picture string `json:"picture"`
+	}
+
+	if err := m.api(ctx, token, http.MethodGet, "/users/@me", nil, &response); err != nil {
+		return nil, err
+	}
+
+	return &types.Viewer{
+		ID:     strconv.Itoa(response.ID),
+		Name:   response.Name,
+		Avatar: response.Picture,
+	}, nil
+}
+
+// SaveProgress records that the user watched up to episode progress. Like
+// the AniList tracker it only moves forward and completes the show on its
+// last episode.
+func (m *MyAnimeList) SaveProgress(ctx context.Context, token *types.OAuthToken, anime *types.AnimeInfo, progress int) error {
+	malID, err := malIDOf(anime)
+	if err != nil {
+		return err
+	}
+
+	var current struct {
+		NumEpisodes  int         `json:"num_episodes"`
+		MyListStatus *listStatus `json:"my_list_status"`
+	}
+	if err := m.api(ctx, token, http.MethodGet, "/anime/"+malID+"?fields=my_list_status,num_episodes", nil, &current); err != nil {
+		return err
+	}
+
+	status := types.ListCurrent
+	if entry := current.MyListStatus; entry != nil {
+		if progress <= entry.NumEpisodesWatched {
+			return nil
+		}
+		if entry.IsRewatching {
+			status = types.ListRepeating
+		}
+	}
+	if current.NumEpisodes > 0 && progress >= current.NumEpisodes {
+		status = types.ListCompleted
+	}
+
+	_, err = m.UpdateEntry(ctx, token, anime, types.ListEntryUpdate{Status: status, Progress: &progress})
+	return err
+}
+
+// UpdateEntry adds the anime to the user's list or changes its entry. MAL
+// scores are whole numbers out of 10, so Score is rounded.
+func (m *MyAnimeList) UpdateEntry(ctx context.Context, token *types.OAuthToken, anime *types.AnimeInfo, update types.ListEntryUpdate) (*types.ListEntry, error) {
+	malID, err := malIDOf(anime)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	if update.Status != "" {
+		status, rewatching := toMALStatus(update.Status)
+		form.Set("status", status)
+		form.Set("is_rewatching", strconv.FormatBool(rewatching))
+	}
+	if update.Progress != nil {
+		form.Set("num_watched_episodes", strconv.Itoa(*update.Progress))
+	}
+	if update.Score != nil {
+		form.Set("score", strconv.Itoa(int(*update.Score+0.5)))
+	}
+
+	var response listStatus
+	if err := m.api(ctx, token, http.MethodPatch, "/anime/"+malID+"/my_list_status", form, &response); err != nil {
+		return nil, err
+	}
+
+	return &types.ListEntry{
+		Status:   fromMALStatus(response.Status, response.IsRewatching),
+		Progress: response.NumEpisodesWatched,
+		Score:    response.Score,
+		Anime:    *anime,
+	}, nil
+}
+
+// MediaLists returns the user's anime lists, or only the list with the given
+// status when it is set. Entries carry MAL IDs only; their AniList ID is empty.
+func (m *MyAnimeList) MediaLists(ctx context.Context, token *types.OAuthToken, status types.ListStatus) ([]types.MediaList, error) {
+	params := url.Values{}
+	params.Set("fields", listNodeFields)
+	params.Set("limit", "1000")
+	params.Set("nsfw", "true")
+	if status != "" {
+		malStatus, _ := toMALStatus(status)
+		params.Set("status", malStatus)
+	}
+
+	entries := make(map[types.ListStatus][]types.ListEntry)
+	path := "/users/@me/animelist?" + params.Encode()
+	for page := 0; page < maxListPages; page++ {
+		var response struct {
+			Data []struct {
+				Node       animeNode  `json:"node"`
+				ListStatus listStatus `json:"list_status"`
+			} `json:"data"`
+			Paging struct {
+				Next string `json:"next"`
+			} `json:"paging"`
+		}
+
+		if err := m.api(ctx, token, http.MethodGet, path, nil, &response); err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Data {
+			entryStatus := fromMALStatus(item.ListStatus.Status, item.ListStatus.IsRewatching)
+			// Rewatches are listed with the shows being watched, as on MAL.
+			listKey := entryStatus
+			if listKey == types.ListRepeating {
+				listKey = types.ListCurrent
+			}
+			entries[listKey] = append(entries[listKey], types.ListEntry{
+				Status:   entryStatus,
+				Progress: item.ListStatus.NumEpisodesWatched,
+				Score:    item.ListStatus.Score,
+				Anime:    mapNodeToAnimeInfo(item.Node),
+			})
+		}
+
+		// The next page is linked by its absolute URL.
+		if !strings.HasPrefix(response.Paging.Next, m.apiURL) {
+			break
+		}
+		path = strings.TrimPrefix(response.Paging.Next, m.apiURL)
+	}
+
+	lists := []types.MediaList{}
+	for _, s := range malStatuses {
+		if len(entries[s.status]) == 0 {
+			continue
+		}
+		lists = append(lists, types.MediaList{
+			Name:    s.name,
+			Status:  s.status,
+			Entries: entries[s.status],
+		})
+	}
+	return lists, nil
+}
+
+// api calls the MAL API on behalf of the token's user and decodes the JSON
+// response into out. A non-nil form is sent as the request body.
+func (m *MyAnimeList) api(ctx context.Context, token *types.OAuthToken, method string, path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, m.apiURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return apperror.Upstream(m.ID(), fmt.Errorf("failed to call MAL API: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return apperror.FromStatus(m.ID(), resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return apperror.UpstreamUnavailable(m.ID(), fmt.Errorf("failed to decode MAL API response: %w", err))
+	}
+	return nil
+}
+
+// malIDOf returns the MAL ID of anime, which AniList leaves at 0 when unknown.
+func malIDOf(anime *types.AnimeInfo) (string, error) {
+	if anime.IDMal == "" || anime.IDMal == "0" {
+		return "", apperror.NotFound("mal", "anime %s has no MyAnimeList ID", anime.ID)
+	}
+	return anime.IDMal, nil
+}
+
+func toMALStatus(status types.ListStatus) (string, bool) {
+	if status == types.ListRepeating {
+		return "watching", true
+	}
+	for _, s := range malStatuses {
+		if s.status == status {
+			return s.mal, false
+		}
+	}
+	return "watching", false
+}
+
+func fromMALStatus(status string, rewatching bool) types.ListStatus {
+	if rewatching {
+		return types.ListRepeating
+	}
+	for _, s := range malStatuses {
+		if s.mal == status {
+			return s.status
+		}
+	}
+	return types.ListCurrent
+}
+
+// mapNodeToAnimeInfo maps the anime of a MAL list entry.
+func mapNodeToAnimeInfo(node animeNode) types.AnimeInfo {
+	info := types.AnimeInfo{
+		IDMal: strconv.Itoa(node.ID),
+		Title: types.Title{
+			Romaji:  node.Title,
+			English: node.AlternativeTitles.En,
+			Native:  node.AlternativeTitles.Ja,
+		},
+		Synonyms:      node.AlternativeTitles.Synonyms,
+		TotalEpisodes: node.NumEpisodes,
+		Format:        types.Format(strings.ToUpper(node.MediaType)),
+		Type:          types.TypeAnime,
+	}
+
+	if node.MainPicture.Large != "" {
+		info.CoverImage = &types.Image{ExtraLarge: node.MainPicture.Large, Large: node.MainPicture.Medium}
+	}
+	if node.Mean != 0 {
+		mean := node.Mean
+		info.Rating = &mean
+	}
+	if node.StartSeason.Year != 0 {
+		year := node.StartSeason.Year
+		info.Year = &year
+		season := types.Season(strings.ToUpper(node.StartSeason.Season))
+		info.Season = &season
+	}
+
+	switch node.Status {
+	case "finished_airing":
+		info.Status = types.StatusFinished
+	case "currently_airing":
+		info.Status = types.StatusReleasing
+	case "not_yet_aired":
+		info.Status = types.StatusNotYetReleased
+	}
+	return info
+}
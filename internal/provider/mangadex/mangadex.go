@@ -0,0 +1,235 @@
+package mangadex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"aniverse/internal/apperror"
+	"aniverse/internal/cache"
+	"aniverse/internal/config"
+	"aniverse/internal/httpclient"
+	"aniverse/internal/provider"
+	"aniverse/internal/types"
+)
+
+var _ provider.ChapterProvider = (*MangaDex)(nil)
+
+// feedPageSize is the largest page the chapter feed returns.
+const feedPageSize = 500
+
+// maxFeedPages bounds how many feed pages FetchChapters reads.
+const maxFeedPages = 10
+
+type MangaDex struct {
+	apiURL   string
+	language string
+	cache    cache.Store
+	client   *httpclient.Client
+}
+
+func NewMangaDex(cfg config.MangaDexConfig, store cache.Store, client *httpclient.Client) *MangaDex {
+	return &MangaDex{
+		apiURL:   strings.TrimSuffix(cfg.APIURL, "/"),
+		language: cfg.Language,
+		cache:    store,
+		client:   client,
+	}
+}
+
+func (m *MangaDex) ID() string {
+	return "mangadex"
+}
+
+func (m *MangaDex) URL() string {
+	return "https://mangadex.org"
+}
+
+func (m *MangaDex) Formats() []types.Format {
+	return []types.Format{
+		types.FormatManga,
+		types.FormatOneShot,
+	}
+}
+
+func (m *MangaDex) NeedsProxy() bool {
+	return false
+}
+
+func (m *MangaDex) UseGoogleTranslate() bool {
+	return false
+}
+
+// SearchManga searches MangaDex by title. Titles are returned in English
+// when available, with the romanized title in Romaji.
+func (m *MangaDex) SearchManga(ctx context.Context, query string) ([]types.MangaInfo, error) {
+	cacheKey := cache.Key(m.ID(), "search", strings.ToLower(query))
+	results := []types.MangaInfo{}
+	if cache.GetJSON(ctx, m.cache, cacheKey, &results) {
+		return results, nil
+	}
+
+	params := url.Values{}
+	params.Set("title", query)
+	params.Set("limit", "20")
+	params.Add("contentRating[]", "safe")
+	params.Add("contentRating[]", "suggestive")
+
+	var response struct {
+		Data []struct {
+			ID         string `json:"id"`
+			Attributes struct {
+				Title     map[string]string   `json:"title"`
+				AltTitles []map[string]string `json:"altTitles"`
+				Year      int                 `json:"year"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+
+	if err := m.get(ctx, "/manga?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+
+	for _, manga := range response.Data {
+		title := types.Title{}
+		titles := []map[string]string{manga.Attributes.Title}
+		titles = append(titles, manga.Attributes.AltTitles...)
+		for _, localized := range titles {
+			for language, value := range localized {
+				switch {
+				case language == "en" && title.English == "":
+					title.English = value
+				case (language == "ja-ro" || language == "en") && title.Romaji == "":
+					title.Romaji = value
+				case language == "ja" && title.Native == "":
+					title.Native = value
+				}
+			}
+		}
+		if title.Romaji == "" {
+			title.Romaji = title.English
+		}
+
+		info := types.MangaInfo{
+			ID:       manga.ID,
+			Title:    title,
+			Type:     types.TypeManga,
+			Chapters: []types.Chapter{},
+		}
+		if manga.Attributes.Year != 0 {
+			year := manga.Attributes.Year
+			info.Year = &year
+		}
+		results = append(results, info)
+	}
+
+	cache.SetJSON(ctx, m.cache, cacheKey, results, cache.TTLSearch)
+	return results, nil
+}
+
+// FetchChapters lists the chapters of a manga in the configured language,
+// in reading order.
+func (m *MangaDex) FetchChapters(ctx context.Context, id string) ([]types.Chapter, error) {
+	cacheKey := cache.Key(m.ID(), "chapters", id, m.language)
+	chapters := []types.Chapter{}
+	if cache.GetJSON(ctx, m.cache, cacheKey, &chapters) {
+		return chapters, nil
+	}
+
+	for page := 0; page < maxFeedPages; page++ {
+		params := url.Values{}
+		params.Add("translatedLanguage[]", m.language)
+		params.Set("order[volume]", "asc")
+		params.Set("order[chapter]", "asc")
+		params.Set("limit", strconv.Itoa(feedPageSize))
+		params.Set("offset", strconv.Itoa(page*feedPageSize))
+
+		var response struct {
+			Data []struct {
+				ID         string `json:"id"`
+				Attributes struct {
+					Chapter     string `json:"chapter"`
+					Volume      string `json:"volume"`
+					Title       string `json:"title"`
+					Pages       int    `json:"pages"`
+					PublishAt   string `json:"publishAt"`
+					ExternalURL string `json:"externalUrl"`
+				} `json:"attributes"`
+			} `json:"data"`
+			Total int `json:"total"`
+		}
+
+		if err := m.get(ctx, "/manga/"+url.PathEscape(id)+"/feed?"+params.Encode(), &response); err != nil {
+			return nil, err
+		}
+
+		for _, chapter := range response.Data {
+			// Chapters hosted elsewhere have no pages on MangaDex.
+			if chapter.Attributes.ExternalURL != "" || chapter.Attributes.Pages == 0 {
+				continue
+			}
+			chapters = append(chapters, types.Chapter{
+				ID:          chapter.ID,
+				Number:      chapter.Attributes.Chapter,
+				Volume:      chapter.Attributes.Volume,
+				Title:       chapter.Attributes.Title,
+				Pages:       chapter.Attributes.Pages,
+				PublishedAt: chapter.Attributes.PublishAt,
+			})
+		}
+
+		if (page+1)*feedPageSize >= response.Total {
+			break
+		}
+	}
+
+	cache.SetJSON(ctx, m.cache, cacheKey, chapters, cache.TTLEpisodes)
+	return chapters, nil
+}
+
+// FetchPages returns the page image URLs of a chapter from its MangaDex@Home
+// server. The URLs expire after a while, so they are not cached.
+func (m *MangaDex) FetchPages(ctx context.Context, chapterID string) ([]types.Page, error) {
+	var response struct {
+		BaseURL string `json:"baseUrl"`
+		Chapter struct {
+			Hash string   `json:"hash"`
+			Data []string `json:"data"`
+		} `json:"chapter"`
+	}
+
+	if err := m.get(ctx, "/at-home/server/"+url.PathEscape(chapterID), &response); err != nil {
+		return nil, err
+	}
+
+	pages := make([]types.Page, 0, len(response.Chapter.Data))
+	for i, file := range response.Chapter.Data {
+		pages = append(pages, types.Page{
+			Index: i + 1,
+			URL:   fmt.Sprintf("%s/data/%s/%s", response.BaseURL, response.Chapter.Hash, file),
+		})
+	}
+	return pages, nil
+}
+
+// get fetches path from the MangaDex API and decodes the JSON response into out.
+func (m *MangaDex) get(ctx context.Context, path string, out interface{}) error {
+	resp, err := m.client.Get(ctx, m.apiURL+path, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return apperror.Upstream(m.ID(), fmt.Errorf("failed to make request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return apperror.FromStatus(m.ID(), resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return apperror.UpstreamUnavailable(m.ID(), fmt.Errorf("failed to decode response: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package provider
+
+import (
+	"context"
+	"sync"
+
+	"aniverse/internal/types"
+)
+
+// Registry holds the providers available to controllers and mappers, in
+// registration order. Earlier registrations take precedence.
+type Registry struct {
+	mu        sync.RWMutex
+	providers []BaseProvider
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds p to the registry, replacing any provider with the same ID.
+func (r *Registry) Register(p BaseProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.providers {
+		if existing.ID() == p.ID() {
+			r.providers[i] = p
+			return
+		}
+	}
+	r.providers = append(r.providers, p)
+}
+
+// Get returns the provider registered under id.
+func (r *Registry) Get(id string) (BaseProvider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.providers {
+		if p.ID() == id {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// All returns every registered provider.
+func (r *Registry) All() []BaseProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]BaseProvider(nil), r.providers...)
+}
+
+// MetaProviders returns the registered providers that implement MetaProvider.
+func (r *Registry) MetaProviders() []MetaProvider {
+	var result []MetaProvider
+	for _, p := range r.All() {
+		if meta, ok := p.(MetaProvider); ok {
+			result = append(result, meta)
+		}
+	}
+	return result
+}
+
+// EpisodeProviders returns the registered providers that implement EpisodeProvider.
+func (r *Registry) EpisodeProviders() []EpisodeProvider {
+	var result []EpisodeProvider
+	for _, p := range r.All() {
+		if episodes, ok := p.(EpisodeProvider); ok {
+			result = append(result, episodes)
+		}
+	}
+	return result
+}
+
+// SourceProviders returns the registered providers that implement SourceProvider.
+func (r *Registry) SourceProviders() []SourceProvider {
+	var result []SourceProvider
+	for _, p := range r.All() {
+		if sources, ok := p.(SourceProvider); ok {
+			result = append(result, sources)
+		}
+	}
+	return result
+}
+
+// MangaProviders returns the registered providers that implement MangaProvider.
+func (r *Registry) MangaProviders() []MangaProvider {
+	var result []MangaProvider
+	for _, p := range r.All() {
+		if manga, ok := p.(MangaProvider); ok {
+			result = append(result, manga)
+		}
+	}
+	return result
+}
+
+// PeopleProviders returns the registered providers that implement PeopleProvider.
+func (r *Registry) PeopleProviders() []PeopleProvider {
+	var result []PeopleProvider
+	for _, p := range r.All() {
+		if people, ok := p.(PeopleProvider); ok {
+			result = append(result, people)
+		}
+	}
+	return result
+}
+
+// Trackers returns the registered providers that implement Tracker.
+func (r *Registry) Trackers() []Tracker {
+	var result []Tracker
+	for _, p := range r.All() {
+		if tracker, ok := p.(Tracker); ok {
+			result = append(result, tracker)
+		}
+	}
+	return result
+}
+
+// ChapterProviders returns the registered providers that implement ChapterProvider.
+func (r *Registry) ChapterProviders() []ChapterProvider {
+	var result []ChapterProvider
+	for _, p := range r.All() {
+		if chapters, ok := p.(ChapterProvider); ok {
+			result = append(result, chapters)
+		}
+	}
+	return result
+}
+
+// Meta returns the preferred MetaProvider, or nil if none is registered.
+func (r *Registry) Meta() MetaProvider {
+	if providers := r.MetaProviders(); len(providers) > 0 {
+		return providers[0]
+	}
+	return nil
+}
+
+// BatchMeta returns the preferred MetaProvider as a BatchMetaProvider, or nil
+// if none is registered. One without batch support looks the IDs up one by one.
+func (r *Registry) BatchMeta() BatchMetaProvider {
+	meta := r.Meta()
+	if meta == nil {
+		return nil
+	}
+	if batch, ok := meta.(BatchMetaProvider); ok {
+		return batch
+	}
+	return sequentialBatch{meta}
+}
+
+// sequentialBatch implements GetMediaBatch with a GetMedia per ID.
+type sequentialBatch struct {
+	MetaProvider
+}
+
+func (s sequentialBatch) GetMediaBatch(ctx context.Context, ids []string) []types.MediaResult {
+	results := make([]types.MediaResult, len(ids))
+	for i, id := range ids {
+		media, err := s.GetMedia(ctx, id)
+		results[i] = types.MediaResult{ID: id, Media: media, Err: err}
+	}
+	return results
+}
+
+// Manga returns the preferred MangaProvider, or nil if none is registered.
+func (r *Registry) Manga() MangaProvider {
+	if providers := r.MangaProviders(); len(providers) > 0 {
+		return providers[0]
+	}
+	return nil
+}
+
+// People returns the preferred PeopleProvider, or nil if none is registered.
+func (r *Registry) People() PeopleProvider {
+	if providers := r.PeopleProviders(); len(providers) > 0 {
+		return providers[0]
+	}
+	return nil
+}
+
+// Discovery returns the preferred MetaProvider that implements
+// DiscoveryProvider, or nil if there is none.
+func (r *Registry) Discovery() DiscoveryProvider {
+	for _, meta := range r.MetaProviders() {
+		if discovery, ok := meta.(DiscoveryProvider); ok {
+			return discovery
+		}
+	}
+	return nil
+}
+
+// EpisodeProvider returns the EpisodeProvider registered under id.
+func (r *Registry) EpisodeProvider(id string) (EpisodeProvider, bool) {
+	p, ok := r.Get(id)
+	if !ok {
+		return nil, false
+	}
+	episodes, ok := p.(EpisodeProvider)
+	return episodes, ok
+}
+
+// SourceProvider returns the SourceProvider registered under id.
+func (r *Registry) SourceProvider(id string) (SourceProvider, bool) {
+	p, ok := r.Get(id)
+	if !ok {
+		return nil, false
+	}
+	sources, ok := p.(SourceProvider)
+	return sources, ok
+}
+
+// ChapterProvider returns the ChapterProvider registered under id.
+func (r *Registry) ChapterProvider(id string) (ChapterProvider, bool) {
+	p, ok := r.Get(id)
+	if !ok {
+		return nil, false
+	}
+	chapters, ok := p.(ChapterProvider)
+	return chapters, ok
+}
+
+// Tracker returns the Tracker registered under id.
+func (r *Registry) Tracker(id string) (Tracker, bool) {
+	p, ok := r.Get(id)
+	if !ok {
+		return nil, false
+	}
+	tracker, ok := p.(Tracker)
+	return tracker, ok
+}
@@ -2,11 +2,15 @@ package tvdb
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
+	"strings"
+
+	"aniverse/internal/config"
+	"aniverse/internal/httpclient"
 )
 
 type AuthResponse struct {
@@ -24,9 +28,10 @@ type SeriesData struct {
 }
 
 type Client struct {
-	APIKey string
-	Token  string
-	Client *http.Client
+	BaseURL string
+	APIKey  string
+	Token   string
+	Client  *httpclient.Client
 }
 
 type Episode struct {
@@ -39,20 +44,21 @@ type EpisodeData struct {
 	Data []Episode `json:"data"`
 }
 
-func NewClient(apiKey string) *Client {
+func NewClient(cfg config.TVDBConfig, client *httpclient.Client) *Client {
 	return &Client{
-		APIKey: apiKey,
-		Client: &http.Client{},
+		BaseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		APIKey:  cfg.APIKey,
+		Client:  client,
 	}
 }
 
-func (c *Client) Authenticate() error {
-	url := "https://api.thetvdb.com/login"
+func (c *Client) Authenticate(ctx context.Context) error {
+	url := c.BaseURL + "/login"
 	payload := map[string]string{
-		"apikey": os.Getenv("TVDB_CLIENT"),
+		"apikey": c.APIKey,
 	}
 	body, _ := json.Marshal(payload)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
@@ -73,9 +79,9 @@ func (c *Client) Authenticate() error {
 	return nil
 }
 
-func (c *Client) SearchSeriesByName(name string) ([]Series, error) {
-	searchURL := fmt.Sprintf("https://api.thetvdb.com/search/series?name=%s", url.QueryEscape(name))
-	req, err := http.NewRequest("GET", searchURL, nil)
+func (c *Client) SearchSeriesByName(ctx context.Context, name string) ([]Series, error) {
+	searchURL := fmt.Sprintf("%s/search/series?name=%s", c.BaseURL, url.QueryEscape(name))
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -96,9 +102,9 @@ func (c *Client) SearchSeriesByName(name string) ([]Series, error) {
 	return data.Data, nil
 }
 
-func (c *Client) GetEpisodes(seriesID int) ([]Episode, error) {
-	episodesURL := fmt.Sprintf("https://api.thetvdb.com/series/%d/episodes", seriesID)
-	req, err := http.NewRequest("GET", episodesURL, nil)
+func (c *Client) GetEpisodes(ctx context.Context, seriesID int) ([]Episode, error) {
+	episodesURL := fmt.Sprintf("%s/series/%d/episodes", c.BaseURL, seriesID)
+	req, err := http.NewRequestWithContext(ctx, "GET", episodesURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// reURIAttribute matches the URI="..." attribute of tags such as EXT-X-KEY.
+var reURIAttribute = regexp.MustCompile(`URI="([^"]*)"`)
+
+// playlistTags reference other playlists through their URI attribute; the
+// URIs of any other tag (keys, init sections) are fetched as segments.
+var playlistTags = []string{"#EXT-X-MEDIA:", "#EXT-X-I-FRAME-STREAM-INF:"}
+
+// RewritePlaylist rewrites every variant, segment and key URI of an m3u8
+// playlist fetched from base so that it points back at the proxy, resolving
+// relative URIs first. Headers are carried into every rewritten link. Lines
+// over 1 MiB fail rather than truncate the playlist.
+func (p *Proxy) RewritePlaylist(playlist []byte, base *url.URL, headers map[string]string) ([]byte, error) {
+	var out bytes.Buffer
+	nextIsVariant := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(playlist))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#"):
+			if strings.HasPrefix(line, "#EXT-X-STREAM-INF:") {
+				nextIsVariant = true
+			}
+			if strings.Contains(line, `URI="`) {
+				kind := KindSegment
+				for _, tag := range playlistTags {
+					if strings.HasPrefix(line, tag) {
+						kind = KindPlaylist
+					}
+				}
+				line = reURIAttribute.ReplaceAllStringFunc(line, func(attribute string) string {
+					uri := reURIAttribute.FindStringSubmatch(attribute)[1]
+					return `URI="` + p.URL(kind, resolve(base, uri), headers) + `"`
+				})
+			}
+		default:
+			kind := KindSegment
+			if nextIsVariant || strings.Contains(strings.ToLower(line), ".m3u8") {
+				kind = KindPlaylist
+			}
+			nextIsVariant = false
+			line = p.URL(kind, resolve(base, line), headers)
+		}
+
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// resolve makes uri absolute relative to the playlist it appeared in.
+func resolve(base *url.URL, uri string) string {
+	ref, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return base.ResolveReference(ref).String()
+}
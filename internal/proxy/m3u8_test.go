@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reProxyLink matches the proxy links in a rewritten playlist.
+var reProxyLink = regexp.MustCompile(`https://aniverse\.test/proxy/[^"\s]+`)
+
+func TestRewritePlaylist(t *testing.T) {
+	base, _ := url.Parse("https://cdn.test/hls/abc/master.m3u8")
+	headers := map[string]string{"Referer": "https://embed.test/e/abc"}
+
+	tests := []struct {
+		name     string
+		playlist string
+		// want lists the kind and target of each rewritten URI, in order.
+		want [][2]string
+		// keep is text that must survive the rewrite unchanged.
+		keep string
+	}{
+		{
+			name:     "relative variants",
+			playlist: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360\n360/index.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=2000000\n../720/stream\n",
+			want: [][2]string{
+				{KindPlaylist, "https://cdn.test/hls/abc/360/index.m3u8"},
+				{KindPlaylist, "https://cdn.test/hls/720/stream"},
+			},
+			keep: "#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360",
+		},
+		{
+			name:     "absolute variant",
+			playlist: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000\nhttps://other.test/v/1080.m3u8?token=x\n",
+			want:     [][2]string{{KindPlaylist, "https://other.test/v/1080.m3u8?token=x"}},
+		},
+		{
+			name:     "root-relative segments",
+			playlist: "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10.0,\n/seg/0.ts\n#EXTINF:10.0,\nseg-1.ts\n#EXT-X-ENDLIST\n",
+			want: [][2]string{
+				{KindSegment, "https://cdn.test/seg/0.ts"},
+				{KindSegment, "https://cdn.test/hls/abc/seg-1.ts"},
+			},
+			keep: "#EXT-X-ENDLIST",
+		},
+		{
+			name:     "key and map",
+			playlist: "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\",IV=0x1\n#EXT-X-MAP:URI=\"https://cdn.test/init.mp4\"\n#EXTINF:4,\nseg.m4s\n",
+			want: [][2]string{
+				{KindSegment, "https://cdn.test/hls/abc/key.bin"},
+				{KindSegment, "https://cdn.test/init.mp4"},
+				{KindSegment, "https://cdn.test/hls/abc/seg.m4s"},
+			},
+			keep: ",IV=0x1",
+		},
+		{
+			name:     "media and i-frame playlists",
+			playlist: "#EXTM3U\n#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aud\",NAME=\"Japanese\",URI=\"audio/jpn.m3u8\"\n#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=1000,URI=\"iframe.m3u8\"\n",
+			want: [][2]string{
+				{KindPlaylist, "https://cdn.test/hls/abc/audio/jpn.m3u8"},
+				{KindPlaylist, "https://cdn.test/hls/abc/iframe.m3u8"},
+			},
+			keep: `NAME="Japanese"`,
+		},
+	}
+
+	p := newTestProxy(time.Unix(1_700_000_000, 0))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewritten, err := p.RewritePlaylist([]byte(tt.playlist), base, headers)
+			if err != nil {
+				t.Fatalf("RewritePlaylist() error = %v", err)
+			}
+			if tt.keep != "" && !strings.Contains(string(rewritten), tt.keep) {
+				t.Errorf("rewritten playlist lost %q:\n%s", tt.keep, rewritten)
+			}
+
+			links := reProxyLink.FindAllString(string(rewritten), -1)
+			if len(links) != len(tt.want) {
+				t.Fatalf("got %d proxy links, want %d:\n%s", len(links), len(tt.want), rewritten)
+			}
+			for i, link := range links {
+				kind, want := tt.want[i][0], tt.want[i][1]
+				target, err := p.Verify(kind, linkQuery(t, link, kind))
+				if err != nil {
+					t.Fatalf("link %d: Verify() error = %v", i, err)
+				}
+				if target.URL != want {
+					t.Errorf("link %d: target = %q, want %q", i, target.URL, want)
+				}
+				if target.Headers["Referer"] != headers["Referer"] {
+					t.Errorf("link %d: headers = %v, want %v", i, target.Headers, headers)
+				}
+			}
+		})
+	}
+}
+
+func TestRewritePlaylistLongLine(t *testing.T) {
+	base, _ := url.Parse("https://cdn.test/index.m3u8")
+	playlist := "#EXTM3U\n" + strings.Repeat("a", 2*1024*1024) + "\n"
+
+	p := newTestProxy(time.Unix(1_700_000_000, 0))
+	if _, err := p.RewritePlaylist([]byte(playlist), base, nil); err == nil {
+		t.Fatal("RewritePlaylist() error = nil, want bufio.ErrTooLong")
+	}
+}
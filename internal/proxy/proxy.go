@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"aniverse/internal/config"
+	"aniverse/internal/types"
+)
+
+// Kinds of proxied resources; each has its own route.
+const (
+	KindPlaylist = "m3u8"
+	KindSegment  = "segment"
+)
+
+// LinkTTL is how long an issued proxy link stays valid. Playlists are
+// rewritten on every fetch, so only a paused player outlives it.
+const LinkTTL = 6 * time.Hour
+
+// Errors returned by Verify.
+var (
+	ErrInvalidSignature = errors.New("invalid proxy signature")
+	ErrExpired          = errors.New("proxy link expired")
+)
+
+// forwardedHeaders are the only source headers sent upstream.
+var forwardedHeaders = []string{"Referer", "Origin", "User-Agent"}
+
+// Proxy issues signed /proxy links and rewrites HLS playlists so that every
+// URI they reference goes back through the proxy. Links are signed so the
+// proxy cannot be used to fetch arbitrary URLs.
+type Proxy struct {
+	prefix string
+	secret []byte
+	now    func() time.Time
+}
+
+// Target is a verified proxy request.
+type Target struct {
+	URL     string
+	Headers map[string]string
+}
+
+// New creates a Proxy. When cfg.Secret is empty a random secret is used,
+// which invalidates issued links on restart.
+func New(cfg config.ProxyConfig) *Proxy {
+	secret := []byte(cfg.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("Cannot generate proxy secret: %v", err)
+		}
+	}
+	return &Proxy{
+		prefix: strings.TrimSuffix(cfg.BaseURL, "/") + "/proxy/",
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+// URL returns the signed proxy link for target, valid for LinkTTL.
+func (p *Proxy) URL(kind string, target string, headers map[string]string) string {
+	encodedHeaders := encodeHeaders(headers)
+	expires := strconv.FormatInt(p.now().Add(LinkTTL).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("url", target)
+	if encodedHeaders != "" {
+		query.Set("h", encodedHeaders)
+	}
+	query.Set("exp", expires)
+	query.Set("sig", p.sign(kind, target, encodedHeaders, expires))
+	return p.prefix + kind + "?" + query.Encode()
+}
+
+// Verify checks the url, h, exp and sig query parameters of a proxy request.
+func (p *Proxy) Verify(kind string, query url.Values) (*Target, error) {
+	target := query.Get("url")
+	encodedHeaders := query.Get("h")
+	expires := query.Get("exp")
+	expected := p.sign(kind, target, encodedHeaders, expires)
+	if target == "" || !hmac.Equal([]byte(query.Get("sig")), []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+
+	expiry, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || p.now().Unix() > expiry {
+		return nil, ErrExpired
+	}
+
+	headers, err := decodeHeaders(encodedHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return &Target{URL: target, Headers: headers}, nil
+}
+
+// RewriteSource returns a copy of source whose stream, subtitle and audio
+// URLs point at the proxy, fetching upstream with source.Headers.
+func (p *Proxy) RewriteSource(source *types.Source) *types.Source {
+	proxied := *source
+	proxied.Sources = make([]types.Quality, len(source.Sources))
+	for i, quality := range source.Sources {
+		if quality.SubURL != "" {
+			quality.SubURL = p.URL(KindPlaylist, quality.SubURL, source.Headers)
+		}
+		if quality.DubURL != "" {
+			quality.DubURL = p.URL(KindPlaylist, quality.DubURL, source.Headers)
+		}
+		proxied.Sources[i] = quality
+	}
+
+	proxied.Subtitles = p.rewriteAll(KindSegment, source.Subtitles, source.Headers)
+	proxied.Audio = p.rewriteAll(KindPlaylist, source.Audio, source.Headers)
+	if source.Thumbnail != "" {
+		proxied.Thumbnail = p.URL(KindSegment, source.Thumbnail, source.Headers)
+	}
+	return &proxied
+}
+
+func (p *Proxy) rewriteAll(kind string, urls []string, headers map[string]string) []string {
+	rewritten := make([]string, len(urls))
+	for i, u := range urls {
+		rewritten[i] = p.URL(kind, u, headers)
+	}
+	return rewritten
+}
+
+// sign returns the hex HMAC of a proxy link's parameters.
+func (p *Proxy) sign(kind string, target string, encodedHeaders string, expires string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(kind + "\n" + target + "\n" + encodedHeaders + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// encodeHeaders keeps the forwarded headers and encodes them for a query string.
+func encodeHeaders(headers map[string]string) string {
+	kept := make(map[string]string)
+	for _, name := range forwardedHeaders {
+		for key, value := range headers {
+			if http.CanonicalHeaderKey(key) == name && value != "" {
+				kept[name] = value
+			}
+		}
+	}
+	if len(kept) == 0 {
+		return ""
+	}
+
+	data, _ := json.Marshal(kept) // Maps keyed by string are sorted and never fail.
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeHeaders(encoded string) (map[string]string, error) {
+	headers := make(map[string]string)
+	if encoded == "" {
+		return headers, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"aniverse/internal/config"
+)
+
+func newTestProxy(now time.Time) *Proxy {
+	p := New(config.ProxyConfig{BaseURL: "https://aniverse.test/", Secret: "secret"})
+	p.now = func() time.Time { return now }
+	return p
+}
+
+// linkQuery returns the query of a proxy link, failing unless it is for kind.
+func linkQuery(t *testing.T, link string, kind string) url.Values {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", link, err)
+	}
+	if want := "/proxy/" + kind; parsed.Path != want {
+		t.Fatalf("path of %q = %q, want %q", link, parsed.Path, want)
+	}
+	return parsed.Query()
+}
+
+func TestURLRoundTrip(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	p := newTestProxy(now)
+	headers := map[string]string{
+		"Referer":       "https://embed.test/e/1",
+		"Authorization": "Bearer dropped",
+	}
+
+	link := p.URL(KindPlaylist, "https://cdn.test/master.m3u8", headers)
+	if !strings.HasPrefix(link, "https://aniverse.test/proxy/m3u8?") {
+		t.Fatalf("URL() = %q, want a link under the base URL", link)
+	}
+
+	query := linkQuery(t, link, KindPlaylist)
+	if got, want := query.Get("exp"), strconv.FormatInt(now.Add(LinkTTL).Unix(), 10); got != want {
+		t.Errorf("exp = %q, want %q", got, want)
+	}
+
+	target, err := p.Verify(KindPlaylist, query)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if target.URL != "https://cdn.test/master.m3u8" {
+		t.Errorf("target URL = %q", target.URL)
+	}
+	if len(target.Headers) != 1 || target.Headers["Referer"] != "https://embed.test/e/1" {
+		t.Errorf("target headers = %v, want only the Referer", target.Headers)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	p := newTestProxy(now)
+	link := p.URL(KindSegment, "https://cdn.test/seg-1.ts", map[string]string{"Referer": "https://embed.test/"})
+
+	tests := []struct {
+		name   string
+		kind   string
+		modify func(query url.Values)
+	}{
+		{"url", KindSegment, func(q url.Values) { q.Set("url", "https://evil.test/") }},
+		{"headers", KindSegment, func(q url.Values) { q.Set("h", encodeHeaders(map[string]string{"Referer": "https://evil.test/"})) }},
+		{"dropped headers", KindSegment, func(q url.Values) { q.Del("h") }},
+		{"expiry", KindSegment, func(q url.Values) { q.Set("exp", strconv.FormatInt(now.Add(365*24*time.Hour).Unix(), 10)) }},
+		{"signature", KindSegment, func(q url.Values) { q.Set("sig", strings.Repeat("0", 32)) }},
+		{"missing signature", KindSegment, func(q url.Values) { q.Del("sig") }},
+		{"kind", KindPlaylist, func(url.Values) {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := linkQuery(t, link, KindSegment)
+			tt.modify(query)
+			if _, err := p.Verify(tt.kind, query); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsOtherSecret(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	link := newTestProxy(now).URL(KindSegment, "https://cdn.test/seg-1.ts", nil)
+
+	other := New(config.ProxyConfig{Secret: "other"})
+	other.now = func() time.Time { return now }
+	if _, err := other.Verify(KindSegment, linkQuery(t, link, KindSegment)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestVerifyExpiry(t *testing.T) {
+	issued := time.Unix(1_700_000_000, 0)
+	p := newTestProxy(issued)
+	query := linkQuery(t, p.URL(KindSegment, "https://cdn.test/seg-1.ts", nil), KindSegment)
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		wantErr error
+	}{
+		{"fresh", issued.Add(time.Minute), nil},
+		{"at expiry", issued.Add(LinkTTL), nil},
+		{"expired", issued.Add(LinkTTL + time.Second), ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.now = func() time.Time { return tt.at }
+			if _, err := p.Verify(KindSegment, query); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -13,16 +13,38 @@ type Media struct {
 	Format          string     `json:"format"`
 	Status          string     `json:"status"`
 	Episodes        int        `json:"episodes"`
+	Chapters        int        `json:"chapters"`
+	Volumes         int        `json:"volumes"`
 	Duration        int        `json:"duration"`
 	Season          string     `json:"season"`
 	SeasonYear      int        `json:"seasonYear"`
+	StartDate       FuzzyDate  `json:"startDate"`
 	Genres          []string   `json:"genres"`
 	Synonyms        []string   `json:"synonyms"`
 	CountryOfOrigin string     `json:"countryOfOrigin"`
 	Tags            []Tag      `json:"tags"`
 	Characters      Characters `json:"characters"`
 	Relations       Relations  `json:"relations"`
+	Staff           Staff      `json:"staff"`
 	Type            string     `json:"type"`
+
+	NextAiringEpisode *AiringScheduleNode `json:"nextAiringEpisode"`
+	AiringSchedule    AiringSchedule      `json:"airingSchedule"`
+}
+
+type AiringScheduleNode struct {
+	Episode  int   `json:"episode"`
+	AiringAt int64 `json:"airingAt"`
+}
+
+type AiringSchedule struct {
+	Nodes []AiringScheduleNode `json:"nodes"`
+}
+
+type FuzzyDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
 }
 
 type Image struct {
@@ -36,22 +58,56 @@ type Tag struct {
 }
 
 type Characters struct {
-	Edges []CharacterEdge `json:"edges"`
+	PageInfo PageInfo        `json:"pageInfo"`
+	Edges    []CharacterEdge `json:"edges"`
 }
 
 type CharacterEdge struct {
-	Node struct {
-		Name struct {
-			Full string `json:"full"`
-		} `json:"name"`
-		Image Image `json:"image"`
-	} `json:"node"`
-	VoiceActors []struct {
-		Name struct {
-			Full string `json:"full"`
-		} `json:"name"`
-		Image Image `json:"image"`
-	} `json:"voiceActors"`
+	Role        string        `json:"role"`
+	Node        CharacterNode `json:"node"`
+	VoiceActors []StaffNode   `json:"voiceActors"`
+}
+
+type CharacterNode struct {
+	ID          int       `json:"id"`
+	Name        Name      `json:"name"`
+	Image       Image     `json:"image"`
+	Description string    `json:"description"`
+	Gender      string    `json:"gender"`
+	Age         string    `json:"age"`
+	DateOfBirth FuzzyDate `json:"dateOfBirth"`
+	Favourites  int       `json:"favourites"`
+}
+
+type Staff struct {
+	PageInfo PageInfo    `json:"pageInfo"`
+	Edges    []StaffEdge `json:"edges"`
+}
+
+type StaffEdge struct {
+	Role string    `json:"role"`
+	Node StaffNode `json:"node"`
+}
+
+type StaffNode struct {
+	ID                 int      `json:"id"`
+	Name               Name     `json:"name"`
+	Image              Image    `json:"image"`
+	LanguageV2         string   `json:"languageV2"`
+	PrimaryOccupations []string `json:"primaryOccupations"`
+	Description        string   `json:"description"`
+	Gender             string   `json:"gender"`
+	Age                int      `json:"age"`
+	HomeTown           string   `json:"homeTown"`
+	YearsActive        []int    `json:"yearsActive"`
+	Favourites         int      `json:"favourites"`
+}
+
+// Name is the name of a character or staff member.
+type Name struct {
+	Full        string   `json:"full"`
+	Native      string   `json:"native"`
+	Alternative []string `json:"alternative"`
 }
 
 type Relations struct {
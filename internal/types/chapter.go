@@ -0,0 +1,17 @@
+package types
+
+// Chapter is a chapter of a manga as listed by a chapter provider.
+type Chapter struct {
+	ID          string `json:"id"`
+	Number      string `json:"chapter"`
+	Volume      string `json:"volume,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Pages       int    `json:"pages"`
+	PublishedAt string `json:"publishedAt,omitempty"`
+}
+
+// Page is a single page image of a chapter.
+type Page struct {
+	Index int    `json:"index"`
+	URL   string `json:"url"`
+}
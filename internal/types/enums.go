@@ -10,6 +10,9 @@ const (
 	FormatSpecial Format = "SPECIAL"
 	FormatTV      Format = "TV"
 	FormatTVShort Format = "TV_SHORT"
+	FormatManga   Format = "MANGA"
+	FormatNovel   Format = "NOVEL"
+	FormatOneShot Format = "ONE_SHOT"
 )
 
 type MediaStatus string
@@ -52,3 +55,66 @@ const (
 	ServerVidStreaming StreamingServer = "VidStreaming"
 	ServerStreamSB     StreamingServer = "StreamSB"
 )
+
+type MediaSort string
+
+const (
+	SortSearchMatch    MediaSort = "SEARCH_MATCH"
+	SortTrendingDesc   MediaSort = "TRENDING_DESC"
+	SortPopularityDesc MediaSort = "POPULARITY_DESC"
+	SortScoreDesc      MediaSort = "SCORE_DESC"
+	SortScore          MediaSort = "SCORE"
+	SortStartDateDesc  MediaSort = "START_DATE_DESC"
+	SortStartDate      MediaSort = "START_DATE"
+	SortTitleRomaji    MediaSort = "TITLE_ROMAJI"
+	SortTitleEnglish   MediaSort = "TITLE_ENGLISH"
+	SortEpisodesDesc   MediaSort = "EPISODES_DESC"
+	SortFavouritesDesc MediaSort = "FAVOURITES_DESC"
+	SortUpdatedAtDesc  MediaSort = "UPDATED_AT_DESC"
+)
+
+// MediaSorts lists every supported MediaSort.
+var MediaSorts = []MediaSort{
+	SortSearchMatch, SortTrendingDesc, SortPopularityDesc, SortScoreDesc, SortScore,
+	SortStartDateDesc, SortStartDate, SortTitleRomaji, SortTitleEnglish,
+	SortEpisodesDesc, SortFavouritesDesc, SortUpdatedAtDesc,
+}
+
+// StaffLanguage is the language of a voice actor.
+type StaffLanguage string
+
+const (
+	LanguageJapanese   StaffLanguage = "JAPANESE"
+	LanguageEnglish    StaffLanguage = "ENGLISH"
+	LanguageKorean     StaffLanguage = "KOREAN"
+	LanguageItalian    StaffLanguage = "ITALIAN"
+	LanguageSpanish    StaffLanguage = "SPANISH"
+	LanguagePortuguese StaffLanguage = "PORTUGUESE"
+	LanguageFrench     StaffLanguage = "FRENCH"
+	LanguageGerman     StaffLanguage = "GERMAN"
+	LanguageHebrew     StaffLanguage = "HEBREW"
+	LanguageHungarian  StaffLanguage = "HUNGARIAN"
+)
+
+// StaffLanguages lists every supported StaffLanguage.
+var StaffLanguages = []StaffLanguage{
+	LanguageJapanese, LanguageEnglish, LanguageKorean, LanguageItalian, LanguageSpanish,
+	LanguagePortuguese, LanguageFrench, LanguageGerman, LanguageHebrew, LanguageHungarian,
+}
+
+// ListStatus is the status of an entry on a user's list.
+type ListStatus string
+
+const (
+	ListCurrent   ListStatus = "CURRENT"
+	ListPlanning  ListStatus = "PLANNING"
+	ListCompleted ListStatus = "COMPLETED"
+	ListDropped   ListStatus = "DROPPED"
+	ListPaused    ListStatus = "PAUSED"
+	ListRepeating ListStatus = "REPEATING"
+)
+
+// ListStatuses lists every supported ListStatus.
+var ListStatuses = []ListStatus{
+	ListCurrent, ListPlanning, ListCompleted, ListDropped, ListPaused, ListRepeating,
+}
@@ -7,12 +7,25 @@ type Episode struct {
 	EpisodeTitle string   `json:"title,omitempty"`
 	IsFiller     bool     `json:"isFiller"`
 	Img          *string  `json:"img,omitempty"`
+	HasSub       bool     `json:"hasSub"`
 	HasDub       bool     `json:"hasDub"`
 	Description  *string  `json:"description,omitempty"`
 	Rating       *float64 `json:"rating,omitempty"`
 	Source       Source   `json:"source,omitempty"`
 }
 
+// EpisodePage is one page of the episodes of an anime.
+type EpisodePage struct {
+	PageInfo PageInfo  `json:"pageInfo"`
+	Results  []Episode `json:"results"`
+}
+
+// EpisodeServer is a server a streaming site offers an episode on.
+type EpisodeServer struct {
+	Name StreamingServer `json:"name"`
+	URL  string          `json:"url"` // Embed URL of the player
+}
+
 // Source holds all relevant streaming information for a video episode.
 type Source struct {
 	Sources       []Quality         `json:"available_qualities"`
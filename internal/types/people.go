@@ -0,0 +1,77 @@
+package types
+
+// CharacterPage is one page of the characters of an anime.
+type CharacterPage struct {
+	PageInfo PageInfo    `json:"pageInfo"`
+	Results  []Character `json:"results"`
+}
+
+// StaffMember is a person credited on an anime.
+type StaffMember struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	NativeName  string   `json:"nativeName,omitempty"`
+	Image       string   `json:"image"`
+	Role        string   `json:"role"`
+	Language    string   `json:"language,omitempty"`
+	Occupations []string `json:"occupations"`
+}
+
+// StaffPage is one page of the staff of an anime.
+type StaffPage struct {
+	PageInfo PageInfo      `json:"pageInfo"`
+	Results  []StaffMember `json:"results"`
+}
+
+// Appearance is a work a character or staff member appears in, with their
+// role in it. Characters lists who a voice actor played in the work.
+type Appearance struct {
+	ID         string      `json:"id"`
+	Title      Title       `json:"title"`
+	Format     Format      `json:"format"`
+	Type       MediaType   `json:"type"`
+	CoverImage string      `json:"coverImage"`
+	Role       string      `json:"role"`
+	Characters []Character `json:"characters,omitempty"`
+}
+
+// AppearancePage is one page of appearances.
+type AppearancePage struct {
+	PageInfo PageInfo     `json:"pageInfo"`
+	Results  []Appearance `json:"results"`
+}
+
+// CharacterDetails describes a character and the works they appear in.
+type CharacterDetails struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	NativeName       string         `json:"nativeName,omitempty"`
+	AlternativeNames []string       `json:"alternativeNames"`
+	Image            string         `json:"image"`
+	Description      *string        `json:"description,omitempty"`
+	Gender           string         `json:"gender,omitempty"`
+	Age              string         `json:"age,omitempty"`
+	DateOfBirth      string         `json:"dateOfBirth,omitempty"`
+	Favourites       int            `json:"favourites"`
+	Appearances      AppearancePage `json:"appearances"`
+}
+
+// StaffDetails describes a staff member, the works they are credited on and,
+// for voice actors, the characters they played.
+type StaffDetails struct {
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	NativeName       string         `json:"nativeName,omitempty"`
+	AlternativeNames []string       `json:"alternativeNames"`
+	Image            string         `json:"image"`
+	Description      *string        `json:"description,omitempty"`
+	Language         string         `json:"language,omitempty"`
+	Occupations      []string       `json:"occupations"`
+	Gender           string         `json:"gender,omitempty"`
+	Age              *int           `json:"age,omitempty"`
+	HomeTown         string         `json:"homeTown,omitempty"`
+	YearsActive      []int          `json:"yearsActive"`
+	Favourites       int            `json:"favourites"`
+	Works            AppearancePage `json:"works"`
+	Roles            AppearancePage `json:"roles"`
+}
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type Title struct {
 	English string `json:"english"`
 	Romaji  string `json:"romaji"`
@@ -13,14 +18,20 @@ type Artwork struct {
 }
 
 type VoiceActor struct {
-	Name  string `json:"name"`
-	Image string `json:"image"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Language string `json:"language"`
 }
 
 type Character struct {
-	VoiceActor *VoiceActor `json:"voiceActor,omitempty"`
-	Image      string      `json:"image"`
-	Name       string      `json:"name"`
+	ID string `json:"id"`
+	// VoiceActor is the first voice actor listed, kept for older clients.
+	VoiceActor  *VoiceActor  `json:"voiceActor,omitempty"`
+	VoiceActors []VoiceActor `json:"voiceActors"`
+	Image       string       `json:"image"`
+	Name        string       `json:"name"`
+	Role        string       `json:"role,omitempty"`
 }
 
 type Relation struct {
@@ -58,10 +69,59 @@ type AnimeInfo struct {
 	Artwork         []Artwork   `json:"artwork"`
 	Relations       []Relation  `json:"relations"`
 	Characters      []Character `json:"characters"`
+
+	NextAiringEpisode *AiringEpisode  `json:"nextAiringEpisode,omitempty"`
+	AiringSchedule    []AiringEpisode `json:"airingSchedule,omitempty"`
+}
+
+// AiringEpisode is a scheduled broadcast of an episode. AiringAt is a Unix
+// timestamp; timeUntilAiring is computed when encoding so that cached values
+// never report a stale countdown.
+type AiringEpisode struct {
+	Episode  int   `json:"episode"`
+	AiringAt int64 `json:"airingAt"`
+}
+
+// TimeUntilAiring returns the seconds left until the episode airs, or 0 once it has.
+func (a AiringEpisode) TimeUntilAiring() int64 {
+	if remaining := a.AiringAt - time.Now().Unix(); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+func (a AiringEpisode) MarshalJSON() ([]byte, error) {
+	type airingEpisode AiringEpisode
+	return json.Marshal(struct {
+		airingEpisode
+		TimeUntilAiring int64 `json:"timeUntilAiring"`
+	}{airingEpisode(a), a.TimeUntilAiring()})
+}
+
+// ScheduleEntry is an episode airing at a point in time, with its anime.
+type ScheduleEntry struct {
+	Episode  int       `json:"episode"`
+	AiringAt int64     `json:"airingAt"`
+	Anime    AnimeInfo `json:"anime"`
+}
+
+// Schedule is the broadcasts airing in a time range, ordered by time.
+// Truncated is set when the range holds more than could be fetched.
+type Schedule struct {
+	Entries   []ScheduleEntry `json:"entries"`
+	Truncated bool            `json:"truncated"`
+}
+
+// ScheduleDay groups the entries airing on one calendar day.
+type ScheduleDay struct {
+	Date    string          `json:"date"`
+	Weekday string          `json:"weekday"`
+	Entries []ScheduleEntry `json:"entries"`
 }
 
 type MangaInfo struct {
 	ID              string      `json:"id"`
+	IDMal           string      `json:"idMal"`
 	Title           Title       `json:"title"`
 	CoverImage      *string     `json:"coverImage,omitempty"`
 	BannerImage     *string     `json:"bannerImage,omitempty"`
@@ -84,4 +144,54 @@ type MangaInfo struct {
 	Characters      []Character `json:"characters"`
 	Author          *string     `json:"author,omitempty"`
 	Publisher       *string     `json:"publisher,omitempty"`
+	Chapters        []Chapter   `json:"chapters"`
+}
+
+// MangaPage is one page of manga results.
+type MangaPage struct {
+	PageInfo PageInfo    `json:"pageInfo"`
+	Results  []MangaInfo `json:"results"`
+}
+
+// PageInfo describes a page of a paginated listing.
+type PageInfo struct {
+	Total       int  `json:"total"`
+	PerPage     int  `json:"perPage"`
+	CurrentPage int  `json:"currentPage"`
+	LastPage    int  `json:"lastPage"`
+	HasNextPage bool `json:"hasNextPage"`
+}
+
+// MediaFilter narrows a media listing. Zero values are ignored.
+type MediaFilter struct {
+	Search          string      `json:"search,omitempty"`
+	Type            MediaType   `json:"type,omitempty"`
+	Formats         []Format    `json:"formats,omitempty"`
+	Genres          []string    `json:"genres,omitempty"`
+	ExcludedGenres  []string    `json:"excludedGenres,omitempty"`
+	Tags            []string    `json:"tags,omitempty"`
+	ExcludedTags    []string    `json:"excludedTags,omitempty"`
+	Year            int         `json:"year,omitempty"`
+	Season          Season      `json:"season,omitempty"`
+	Status          MediaStatus `json:"status,omitempty"`
+	CountryOfOrigin string      `json:"countryOfOrigin,omitempty"`
+	MinScore        float64     `json:"minScore,omitempty"` // On the 0-10 scale of AnimeInfo.Rating
+	MinEpisodes     int         `json:"minEpisodes,omitempty"`
+	MaxEpisodes     int         `json:"maxEpisodes,omitempty"`
+	Sort            []MediaSort `json:"sort,omitempty"`
+	Page            int         `json:"page,omitempty"`
+	PerPage         int         `json:"perPage,omitempty"`
+}
+
+// AnimePage is one page of anime results.
+type AnimePage struct {
+	PageInfo PageInfo    `json:"pageInfo"`
+	Results  []AnimeInfo `json:"results"`
+}
+
+// MediaResult is the outcome of one ID of a batch lookup: either Media or Err.
+type MediaResult struct {
+	ID    string
+	Media *AnimeInfo
+	Err   error
 }
@@ -0,0 +1,44 @@
+package types
+
+import "time"
+
+// OAuthToken is a user's token for a tracking site.
+type OAuthToken struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// Expired reports whether the token has expired, or is about to.
+func (t OAuthToken) Expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().Add(time.Minute).After(t.ExpiresAt)
+}
+
+// Viewer is the user signed in to a tracking site.
+type Viewer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// ListEntry is an anime on a user's list with their progress.
+type ListEntry struct {
+	Status   ListStatus `json:"status"`
+	Progress int        `json:"progress"`
+	Score    float64    `json:"score"`
+	Anime    AnimeInfo  `json:"anime"`
+}
+
+// MediaList is one of a user's lists, e.g. Watching or Completed.
+type MediaList struct {
+	Name    string      `json:"name"`
+	Status  ListStatus  `json:"status,omitempty"`
+	Entries []ListEntry `json:"entries"`
+}
+
+// ListEntryUpdate changes a list entry; nil fields are left as they are.
+type ListEntryUpdate struct {
+	Status   ListStatus `json:"status,omitempty"`
+	Progress *int       `json:"progress,omitempty"`
+	Score    *float64   `json:"score,omitempty"`
+}
@@ -1,44 +1,76 @@
 package util
 
 import (
-	"crypto/sha256"
+	"crypto/rand"
 	"encoding/base64"
-	"errors"
-	"math/rand"
+	"fmt"
+	"sync"
 	"time"
 )
 
-// GenerateCodeVerifier creates a random string for PKCE (min length: 43, max length: 128)
-func GenerateCodeVerifier() string {
-	rand.Seed(time.Now().UnixNano())
-	verifier := make([]byte, 43)
-	for i := range verifier {
-		verifier[i] = byte(rand.Intn(26) + 97) // Generate random lowercase letters (a-z)
+// GenerateCodeVerifier creates a random string for PKCE (min length: 43, max length: 128).
+// 48 random bytes encode to 64 URL-safe characters. MAL only supports the
+// plain method, where the code challenge is the verifier itself.
+func GenerateCodeVerifier() (string, error) {
+	buf := make([]byte, 48)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating code verifier: %w", err)
 	}
-	return string(verifier)
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
 
-// GenerateCodeChallenge creates the code_challenge using SHA256 hash (for enhanced security)
-// Though MAL supports plain, using a challenge with SHA256 is more secure
-func GenerateCodeChallenge(verifier string) (string, error) {
-	if len(verifier) < 43 || len(verifier) > 128 {
-		return "", errors.New("code_verifier must be between 43 and 128 characters")
+// StateStore holds the code verifier of each pending OAuth login under its
+// state. Entries expire after the TTL and can be taken only once. It is safe
+// for concurrent use.
+type StateStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]stateEntry
+}
+
+type stateEntry struct {
+	verifier  string
+	expiresAt time.Time
+}
+
+// NewStateStore creates a StateStore whose entries live for ttl.
+func NewStateStore(ttl time.Duration) *StateStore {
+	return &StateStore{
+		ttl:     ttl,
+		entries: make(map[string]stateEntry),
 	}
-	hash := sha256.Sum256([]byte(verifier))
-	challenge := base64.RawURLEncoding.EncodeToString(hash[:])
-	return challenge, nil
 }
 
-// In-memory storage for code verifiers (could replace with a session store or database)
-var codeVerifierStore = make(map[string]string)
+// StoreCodeVerifier stores the code verifier associated with the given state.
+func (s *StateStore) StoreCodeVerifier(state, verifier string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Abandoned logins are dropped here rather than by a background sweeper.
+	now := time.Now()
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
 
-// StoreCodeVerifier stores the code verifier in memory (associated with the given state)
-func StoreCodeVerifier(state, verifier string) {
-	codeVerifierStore[state] = verifier
+	s.entries[state] = stateEntry{verifier: verifier, expiresAt: now.Add(s.ttl)}
 }
 
-// GetCodeVerifier retrieves the code verifier for the given state (returns a boolean indicating existence)
-func GetCodeVerifier(state string) (string, bool) {
-	verifier, exists := codeVerifierStore[state]
-	return verifier, exists
+// GetCodeVerifier removes and returns the code verifier for the given state
+// (returns a boolean indicating whether it existed and had not expired).
+func (s *StateStore) GetCodeVerifier(state string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[state]
+	if !exists {
+		return "", false
+	}
+	delete(s.entries, state)
+
+	if time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+	return entry.verifier, true
 }
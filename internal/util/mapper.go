@@ -140,6 +140,18 @@ func FindBestMatch(mainString string, targets []string) string {
 	return bestMatch
 }
 
+// MatchScore returns the best Jaro-Winkler similarity between candidate and any of the given titles.
+func MatchScore(title types.Title, candidate string) float64 {
+	score := JaroWinkler(title.Romaji, candidate)
+	if s := JaroWinkler(title.English, candidate); s > score {
+		score = s
+	}
+	if s := JaroWinkler(title.Native, candidate); s > score {
+		score = s
+	}
+	return score
+}
+
 // FindOriginalTitle finds the best matching title among the given titles
 func FindOriginalTitle(title types.Title, titles []string) string {
 	romajiBestMatch := FindBestMatch(title.Romaji, titles)
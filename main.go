@@ -2,8 +2,14 @@ package main
 
 import (
 	"aniverse/cmd/aniverse"
+	"aniverse/internal/config"
+	"log"
 )
 
 func main() {
-	aniverse.Start()
+	cfg, err := config.Load("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	aniverse.Start(cfg)
 }